@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"regexp"
+	"strings"
+)
+
+var (
+	// 42, 42-50, 42:7 or 42:7-50:3 followed by optional text (eg: grep -n, rg --vimgrep)
+	colonLineRegexp = regexp.MustCompile(`^([0-9]+(?::[0-9]+)?(?:-[0-9]+(?::[0-9]+)?)?)(?::.*)?$`)
+	// L10, L10-L20 or L10C5-L12C20
+	fragmentLineRegexp = regexp.MustCompile(`^L([0-9]+)(?:C([0-9]+))?(?:-L?([0-9]+)(?:C([0-9]+))?)?$`)
+)
+
+// parsePathArgument splits editor style arguments into the path and the line option
+//   main.go:42      => main.go, 42
+//   main.go:42:7    => main.go, 42:7
+//   main.go:42-50   => main.go, 42-50
+//   main.go#L10-L20 => main.go, 10-20
+// An argument which exists as a file is never split, so file names may contain colons.
+func parsePathArgument(arg string) (string, string) {
+	if exists(arg) {
+		return arg, ""
+	}
+
+	if i := strings.LastIndex(arg, "#"); i > 0 {
+		if m := fragmentLineRegexp.FindStringSubmatch(arg[i+1:]); m != nil {
+			line := m[1] + prefixIfNotEmpty(":", m[2])
+			if m[3] != "" {
+				line += "-" + m[3] + prefixIfNotEmpty(":", m[4])
+			}
+			return arg[:i], line
+		}
+	}
+
+	var candidates []int
+	for i := 1; i < len(arg); i++ {
+		if arg[i] == ':' && colonLineRegexp.MatchString(arg[i+1:]) {
+			candidates = append(candidates, i)
+		}
+	}
+	// Prefer the longest path which exists
+	for j := len(candidates) - 1; j >= 0; j-- {
+		if exists(arg[:candidates[j]]) {
+			return splitColonLine(arg, candidates[j])
+		}
+	}
+	// The path may not exist (eg: bare repository), take the first line number
+	if len(candidates) > 0 {
+		return splitColonLine(arg, candidates[0])
+	}
+	return arg, ""
+}
+
+func splitColonLine(arg string, i int) (string, string) {
+	m := colonLineRegexp.FindStringSubmatch(arg[i+1:])
+	return arg[:i], m[1]
+}
+
+func prefixIfNotEmpty(prefix, s string) string {
+	if s == "" {
+		return ""
+	}
+	return prefix + s
+}
+
+func exists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParsePathArgument(t *testing.T) {
+	testDir := mkTempDir()
+	defer os.RemoveAll(testDir)
+
+	os.WriteFile(filepath.Join(testDir, "main.go"), []byte(""), 0666)
+	os.WriteFile(filepath.Join(testDir, "a:1.go"), []byte(""), 0666)
+	os.WriteFile(filepath.Join(testDir, "b:2"), []byte(""), 0666)
+
+	mainGo := filepath.Join(testDir, "main.go")
+	cases := []struct {
+		input    string
+		wantPath string
+		wantLine string
+	}{
+		{input: mainGo, wantPath: mainGo, wantLine: ""},
+		{input: mainGo + ":42", wantPath: mainGo, wantLine: "42"},
+		{input: mainGo + ":42-50", wantPath: mainGo, wantLine: "42-50"},
+		{input: mainGo + ":42:7", wantPath: mainGo, wantLine: "42:7"},
+		{input: mainGo + ":42:7:func main() {", wantPath: mainGo, wantLine: "42:7"},
+		{input: mainGo + ":42:func main() {", wantPath: mainGo, wantLine: "42"},
+		{input: mainGo + "#L10", wantPath: mainGo, wantLine: "10"},
+		{input: mainGo + "#L10-L20", wantPath: mainGo, wantLine: "10-20"},
+		{input: mainGo + "#L10C5-L12C20", wantPath: mainGo, wantLine: "10:5-12:20"},
+		{input: filepath.Join(testDir, "a:1.go") + ":3", wantPath: filepath.Join(testDir, "a:1.go"), wantLine: "3"},
+		{input: filepath.Join(testDir, "b:2"), wantPath: filepath.Join(testDir, "b:2"), wantLine: ""},
+		{input: filepath.Join(testDir, "b:2") + ":5", wantPath: filepath.Join(testDir, "b:2"), wantLine: "5"},
+		{input: "not/exist.go:42:7", wantPath: "not/exist.go", wantLine: "42:7"},
+		{input: "not/exist.go", wantPath: "not/exist.go", wantLine: ""},
+	}
+	for _, c := range cases {
+		gotPath, gotLine := parsePathArgument(c.input)
+		if gotPath != c.wantPath || gotLine != c.wantLine {
+			t.Errorf("'%s' want '%s' '%s', got '%s' '%s'\n", c.input, c.wantPath, c.wantLine, gotPath, gotLine)
+		}
+	}
+}
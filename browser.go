@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/skratchdot/open-golang/open"
+)
+
+const gitConfigBrowserName string = "gh-open.browser"
+
+var errNoDisplay = errors.New("no display is available in the SSH session")
+
+// browserCommands returns the browser (or opener) command by --browser, git config gh-open.browser
+// or $BROWSER in this order, empty for the system default.
+// Only $BROWSER is a colon separated list of commands to try in order, the others may contain colons (eg: C:\...).
+func browserCommands(flag, config string, getenv func(string) string) []string {
+	if flag != "" {
+		return []string{flag}
+	}
+	if config != "" {
+		return []string{config}
+	}
+	if env := getenv("BROWSER"); env != "" {
+		return strings.Split(env, ":")
+	}
+	return nil
+}
+
+// openURL opens the url by the first browser command which succeeds, or by the system default.
+// The command runs in the foreground (eg: a terminal browser as w3m), gh-open waits for it to exit.
+func openURL(url string, browsers []string) error {
+	if len(browsers) == 0 {
+		if isHeadlessSSH(os.Getenv, runtime.GOOS) {
+			return errNoDisplay
+		}
+		return open.Run(url)
+	}
+
+	var err error
+	for _, command := range browsers {
+		args := browserArgs(command, url)
+		if len(args) == 0 {
+			continue
+		}
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		err = cmd.Run()
+		if err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// browserArgs splits the command and puts the url in place of %s, or appends it
+//   "firefox --new-tab" => firefox --new-tab <url>
+func browserArgs(command, url string) []string {
+	args := strings.Fields(command)
+	replaced := false
+	for i, arg := range args {
+		if strings.Contains(arg, "%s") {
+			args[i] = strings.ReplaceAll(arg, "%s", url)
+			replaced = true
+		}
+	}
+	if len(args) > 0 && !replaced {
+		args = append(args, url)
+	}
+	return args
+}
+
+// isHeadlessSSH reports whether we are in an SSH session without a display to open a browser on
+func isHeadlessSSH(getenv func(string) string, goos string) bool {
+	if goos == "darwin" || goos == "windows" {
+		return false
+	}
+	if getenv("SSH_CONNECTION") == "" && getenv("SSH_TTY") == "" {
+		return false
+	}
+	return getenv("DISPLAY") == "" && getenv("WAYLAND_DISPLAY") == ""
+}
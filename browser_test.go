@@ -0,0 +1,82 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBrowserCommands(t *testing.T) {
+	cases := []struct {
+		flag   string
+		config string
+		env    string
+		want   []string
+	}{
+		{flag: "firefox", config: "chromium", env: "lynx", want: []string{"firefox"}},
+		{flag: "", config: "chromium", env: "lynx", want: []string{"chromium"}},
+		{flag: "", config: "", env: "lynx", want: []string{"lynx"}},
+		{flag: "", config: "", env: "xdg-open:lynx", want: []string{"xdg-open", "lynx"}},
+		{flag: `C:\Program Files\Firefox\firefox.exe`, config: "", env: "lynx", want: []string{`C:\Program Files\Firefox\firefox.exe`}},
+		{flag: "", config: "open -a Foo:Bar", env: "lynx", want: []string{"open -a Foo:Bar"}},
+		{flag: "", config: "", env: "", want: nil},
+	}
+	for _, c := range cases {
+		getenv := func(name string) string { return map[string]string{"BROWSER": c.env}[name] }
+		got := browserCommands(c.flag, c.config, getenv)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("want %v, got %v\n", c.want, got)
+		}
+	}
+}
+
+func TestBrowserArgs(t *testing.T) {
+	url := "https://github.com/inouet/gh-open"
+	cases := []struct {
+		command string
+		want    []string
+	}{
+		{command: "firefox", want: []string{"firefox", url}},
+		{command: "firefox --new-tab", want: []string{"firefox", "--new-tab", url}},
+		{command: "tmux new-window w3m %s", want: []string{"tmux", "new-window", "w3m", url}},
+		{command: "open-url --url=%s", want: []string{"open-url", "--url=" + url}},
+		{command: "", want: []string{}},
+	}
+	for _, c := range cases {
+		got := browserArgs(c.command, url)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("'%s' want %v, got %v\n", c.command, c.want, got)
+		}
+	}
+}
+
+func TestIsHeadlessSSH(t *testing.T) {
+	cases := []struct {
+		goos string
+		env  map[string]string
+		want bool
+	}{
+		{goos: "linux", env: map[string]string{"SSH_CONNECTION": "10.0.0.1 22 10.0.0.2 22"}, want: true},
+		{goos: "linux", env: map[string]string{"SSH_TTY": "/dev/pts/0", "DISPLAY": "localhost:10.0"}, want: false},
+		{goos: "linux", env: map[string]string{}, want: false},
+		{goos: "darwin", env: map[string]string{"SSH_TTY": "/dev/ttys000"}, want: false},
+	}
+	for _, c := range cases {
+		getenv := func(name string) string { return c.env[name] }
+		got := isHeadlessSSH(getenv, c.goos)
+		if got != c.want {
+			t.Errorf("%s %v want %v, got %v\n", c.goos, c.env, c.want, got)
+		}
+	}
+}
+
+func TestOpenURL(t *testing.T) {
+	// The first command of the list which succeeds opens the url
+	err := openURL("https://github.com/inouet/gh-open", []string{"false", "true %s"})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	err = openURL("https://github.com/inouet/gh-open", []string{"false"})
+	if err == nil {
+		t.Errorf("expected an error")
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+var errNoClipboard = errors.New("no clipboard is available")
+
+// copyToClipboard writes the text to the clipboard by the first available backend,
+// or by the OSC 52 escape sequence in a remote SSH session.
+func copyToClipboard(text string) error {
+	command := clipboardCommand(os.Getenv, exec.LookPath, runtime.GOOS)
+	if command != nil {
+		cmd := exec.Command(command[0], command[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		err := cmd.Run()
+		if err != nil {
+			return fmt.Errorf("%s failed: %v", command[0], err)
+		}
+		return nil
+	}
+
+	if os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CONNECTION") != "" {
+		tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+		if err != nil {
+			return errNoClipboard
+		}
+		defer tty.Close()
+		return writeOSC52(tty, text, os.Getenv("TMUX") != "")
+	}
+	return errNoClipboard
+}
+
+// clipboardCommand returns the command copying its stdin to the clipboard, or nil
+func clipboardCommand(getenv func(string) string, lookPath func(string) (string, error), goos string) []string {
+	var candidates [][]string
+	switch goos {
+	case "darwin":
+		candidates = [][]string{{"pbcopy"}}
+	case "windows":
+		candidates = [][]string{{"clip.exe"}}
+	default:
+		if getenv("WAYLAND_DISPLAY") != "" {
+			candidates = append(candidates, []string{"wl-copy"})
+		}
+		if getenv("DISPLAY") != "" {
+			candidates = append(candidates,
+				[]string{"xclip", "-selection", "clipboard"},
+				[]string{"xsel", "--clipboard", "--input"},
+			)
+		}
+		// WSL
+		candidates = append(candidates, []string{"clip.exe"})
+	}
+
+	for _, command := range candidates {
+		if _, err := lookPath(command[0]); err == nil {
+			return command
+		}
+	}
+	return nil
+}
+
+// writeOSC52 writes the OSC 52 sequence setting the clipboard of the terminal,
+// wrapped for tmux which does not pass it through otherwise.
+func writeOSC52(w io.Writer, text string, tmux bool) error {
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+	if tmux {
+		seq = "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	}
+	_, err := io.WriteString(w, seq)
+	return err
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestClipboardCommand(t *testing.T) {
+	cases := []struct {
+		goos      string
+		env       map[string]string
+		installed []string
+		want      []string
+	}{
+		{goos: "darwin", installed: []string{"pbcopy"}, want: []string{"pbcopy"}},
+		{goos: "linux", env: map[string]string{"WAYLAND_DISPLAY": "wayland-0", "DISPLAY": ":0"}, installed: []string{"wl-copy", "xclip"}, want: []string{"wl-copy"}},
+		{goos: "linux", env: map[string]string{"DISPLAY": ":0"}, installed: []string{"wl-copy", "xclip"}, want: []string{"xclip", "-selection", "clipboard"}},
+		{goos: "linux", env: map[string]string{"DISPLAY": ":0"}, installed: []string{"xsel"}, want: []string{"xsel", "--clipboard", "--input"}},
+		{goos: "linux", installed: []string{"xclip", "clip.exe"}, want: []string{"clip.exe"}},
+		{goos: "linux", installed: []string{"xclip"}, want: nil},
+	}
+	for _, c := range cases {
+		getenv := func(name string) string { return c.env[name] }
+		lookPath := func(file string) (string, error) {
+			for _, installed := range c.installed {
+				if installed == file {
+					return "/usr/bin/" + file, nil
+				}
+			}
+			return "", errors.New("not found")
+		}
+		got := clipboardCommand(getenv, lookPath, c.goos)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s %v want %v, got %v\n", c.goos, c.env, c.want, got)
+		}
+	}
+}
+
+func TestWriteOSC52(t *testing.T) {
+	var buf bytes.Buffer
+	writeOSC52(&buf, "https://github.com", false)
+	want := "\x1b]52;c;aHR0cHM6Ly9naXRodWIuY29t\a"
+	if buf.String() != want {
+		t.Errorf("want %q, got %q\n", want, buf.String())
+	}
+
+	buf.Reset()
+	writeOSC52(&buf, "https://github.com", true)
+	want = "\x1bPtmux;\x1b\x1b]52;c;aHR0cHM6Ly9naXRodWIuY29t\a\x1b\\"
+	if buf.String() != want {
+		t.Errorf("want %q, got %q\n", want, buf.String())
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/inouet/gh-open/ghopen"
+)
+
+// convertOptions are the options of gh-open convert
+type convertOptions struct {
+	To   string `long:"to" required:"yes" value-name:"HOST" description:"Host to convert the urls for (eg: github.com)"`
+	Repo string `long:"repo" value-name:"OWNER/NAME" description:"Repository on the host, the same path as the url when not given"`
+}
+
+var convertOpts convertOptions
+
+func (convertOptions) Usage() string {
+	return "[convert-OPTIONS] URL..."
+}
+
+// convertURLs prints the urls converted for the host of --to, in the format of --format or --template
+func convertURLs(urls []string) int {
+	if len(urls) == 0 {
+		printError(usageError("gh-open convert needs the urls to convert"))
+		return statusUsage
+	}
+	status := statusOK
+	for _, rawURL := range urls {
+		loc, err := ghopen.ConvertURL(rawURL, convertOpts.To, convertOpts.Repo)
+		if err == nil {
+			var output string
+			output, err = formatLocation(loc, opts.Format, opts.Template)
+			if err == nil {
+				fmt.Println(output)
+			}
+		}
+		if err != nil {
+			// The errors of the url (eg: not a web url) end with the url
+			if len(urls) > 1 && !strings.Contains(err.Error(), rawURL) {
+				err = fmt.Errorf("%s: %w", rawURL, err)
+			}
+			printError(err)
+			if status == statusOK {
+				status = exitStatus(err)
+			}
+		}
+	}
+	return status
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// editorCommand returns $VISUAL or $EDITOR, empty when neither is set (or only spaces)
+func editorCommand(getenv func(string) string) string {
+	if visual := strings.TrimSpace(getenv("VISUAL")); visual != "" {
+		return visual
+	}
+	return strings.TrimSpace(getenv("EDITOR"))
+}
+
+// openEditor opens the file at the line in the editor
+func openEditor(editor, path string, line int) error {
+	args := editorArgs(editor, path, line)
+	if len(args) == 0 {
+		return errors.New("no editor, set $VISUAL or $EDITOR")
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// editorArgs splits the command and appends the file at the line in the syntax of the editor
+//   vim, emacs, nano, ... => vim +10 path
+//   code               => code --goto path:10
+//   subl, zed, hx      => subl path:10
+func editorArgs(editor, path string, line int) []string {
+	args := strings.Fields(editor)
+	if len(args) == 0 {
+		return nil
+	}
+	if line == 0 {
+		return append(args, path)
+	}
+	switch strings.TrimSuffix(filepath.Base(args[0]), ".exe") {
+	case "code", "code-insiders", "codium", "cursor":
+		return append(args, "--goto", fileLine(path, line))
+	case "subl", "zed", "hx":
+		return append(args, fileLine(path, line))
+	}
+	return append(args, fmt.Sprintf("+%d", line), path)
+}
+
+// fileLine returns path:line, or the path without the line
+func fileLine(path string, line int) string {
+	if line == 0 {
+		return path
+	}
+	return fmt.Sprintf("%s:%d", path, line)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEditorCommand(t *testing.T) {
+	cases := []struct {
+		env  map[string]string
+		want string
+	}{
+		{env: map[string]string{"VISUAL": "code --wait", "EDITOR": "vim"}, want: "code --wait"},
+		{env: map[string]string{"EDITOR": "vim"}, want: "vim"},
+		{env: map[string]string{}, want: ""},
+		{env: map[string]string{"VISUAL": "  ", "EDITOR": "vim"}, want: "vim"},
+		{env: map[string]string{"EDITOR": " \t"}, want: ""},
+	}
+	for _, c := range cases {
+		got := editorCommand(func(name string) string { return c.env[name] })
+		if got != c.want {
+			t.Errorf("want '%s', got '%s'\n", c.want, got)
+		}
+	}
+}
+
+func TestEditorArgs(t *testing.T) {
+	path := "/src/gh-open/main.go"
+	cases := []struct {
+		editor string
+		line   int
+		want   []string
+	}{
+		{editor: "vim", line: 10, want: []string{"vim", "+10", path}},
+		{editor: "emacsclient -t", line: 10, want: []string{"emacsclient", "-t", "+10", path}},
+		{editor: "code --wait", line: 10, want: []string{"code", "--wait", "--goto", path + ":10"}},
+		{editor: "/usr/local/bin/subl", line: 10, want: []string{"/usr/local/bin/subl", path + ":10"}},
+		{editor: "vim", line: 0, want: []string{"vim", path}},
+		{editor: "", line: 10, want: nil},
+		{editor: " ", line: 0, want: nil},
+	}
+	for _, c := range cases {
+		got := editorArgs(c.editor, path, c.line)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("'%s' want %v, got %v\n", c.editor, c.want, got)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package ghopen
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Errors of Resolve, to be tested by errors.Is
+var (
+	ErrNotRepository   = errors.New("not a git repository (or any of the parent directories)")
+	ErrNoRemote        = errors.New("no remote")
+	ErrUnsupportedHost = errors.New("unknown git service")
+	ErrNotExist        = errors.New("no such file or directory")
+	ErrUnknownRevision = errors.New("unknown revision")
+	ErrNotPushed       = errors.New("not pushed")
+	ErrInvalidLine     = errors.New("invalid line")
+	ErrNoMatch         = errors.New("no match")
+	ErrInvalidOption   = errors.New("invalid option")
+)
+
+// GitError is the error of a git command, to be tested by errors.As
+type GitError struct {
+	Args   []string // Arguments of git
+	Stderr string   // Standard error output of git
+	Err    error    // Error of the process (eg: *exec.ExitError)
+}
+
+func (e *GitError) Error() string {
+	msg := "git command failed: git " + strings.Join(e.Args, " ")
+	if e.Stderr != "" {
+		msg += ": " + e.Stderr
+	} else if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *GitError) Unwrap() error {
+	return e.Err
+}
+
+// kindError is an error of its own message, which is the kind of error for errors.Is
+type kindError struct {
+	msg  string
+	kind error
+}
+
+func (e *kindError) Error() string {
+	return e.msg
+}
+
+func (e *kindError) Unwrap() error {
+	return e.kind
+}
+
+// errorf returns the formatted error of the kind
+//   errorf(ErrNotExist, "%s does not exist at %s", path, ref)
+func errorf(kind error, format string, a ...interface{}) error {
+	return &kindError{msg: fmt.Sprintf(format, a...), kind: kind}
+}
@@ -0,0 +1,69 @@
+package ghopen
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGitError(t *testing.T) {
+	testDir := mkTempDir()
+	defer os.RemoveAll(testDir)
+
+	mkGitRepo(t, testDir, "")
+	git, _ := newGit(context.Background(), nil, testDir)
+	_, err := git.exec("rev-parse", "--verify", "no-such-ref")
+
+	var gitErr *GitError
+	if !errors.As(err, &gitErr) {
+		t.Fatalf("want *GitError, got %T %v", err, err)
+	}
+	if gitErr.Stderr == "" || !strings.Contains(err.Error(), gitErr.Stderr) {
+		t.Errorf("want the stderr of git in the error, got '%v'\n", err)
+	}
+	if !strings.HasPrefix(err.Error(), "git command failed: git rev-parse --verify no-such-ref") {
+		t.Errorf("want the arguments in the error, got '%v'\n", err)
+	}
+}
+
+func TestErrorKinds(t *testing.T) {
+	testDir := mkTempDir()
+	defer os.RemoveAll(testDir)
+
+	noRepoDir := filepath.Join(testDir, "norepo")
+	os.MkdirAll(noRepoDir, 0777)
+	noRemoteDir := filepath.Join(testDir, "noremote")
+	mkGitRepo(t, noRemoteDir, "")
+	localDir := filepath.Join(testDir, "local")
+	mkGitRepo(t, localDir, "/path/to/repo.git")
+	githubDir := filepath.Join(testDir, "github")
+	mkGitRepo(t, githubDir, "https://github.com/inouet/gh-open.git")
+
+	cases := map[string]struct {
+		path string
+		opts Options
+		want error
+	}{
+		"not-repository": {path: noRepoDir, want: ErrNotRepository},
+		"no-remote":      {path: filepath.Join(noRemoteDir, "README.md"), want: ErrNoRemote},
+		"no-host":        {path: filepath.Join(localDir, "README.md"), want: ErrUnsupportedHost},
+		"no-file":        {path: filepath.Join(githubDir, "NOFILE.md"), want: ErrNotExist},
+		"no-revision":    {path: filepath.Join(githubDir, "README.md"), opts: Options{Ref: "v9.9.9"}, want: ErrUnknownRevision},
+		"out-of-range":   {path: filepath.Join(githubDir, "README.md"), opts: Options{Lines: testLines(5, 0)}, want: ErrInvalidLine},
+		"no-match":       {path: filepath.Join(githubDir, "README.md"), opts: Options{Match: "line9"}, want: ErrNoMatch},
+	}
+	for name, c := range cases {
+		_, err := Resolve(context.Background(), c.path, c.opts)
+		if !errors.Is(err, c.want) {
+			t.Errorf("%s want %v, got %v\n", name, c.want, err)
+		}
+	}
+
+	_, err := ParseLines("20-10")
+	if !errors.Is(err, ErrInvalidLine) || err.Error() != "invalid line range: 20-10" {
+		t.Errorf("want ErrInvalidLine, got %v\n", err)
+	}
+}
@@ -0,0 +1,222 @@
+package ghopen
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// Values of Resolver.Backend (--backend)
+const (
+	BackendAuto string = "auto" // exec when the git command is available, go otherwise
+	BackendExec string = "exec" // Run the git command
+	BackendGo   string = "go"   // Read the files of the git directory, without the git command
+)
+
+// backend reads the repository for git.
+// The metadata (the repository, config and refs) is read by the methods,
+// and the other commands which read the objects (eg: diff, ls-tree) are given to run.
+type backend interface {
+	// revParse finds the repository of git.dir
+	revParse(git git) repoInfo
+	// configs returns the config of the repository, or of the file relative to the top directory
+	// (eg: .gitmodules), keyed as git config --get-regexp prints the names
+	configs(git git, file string) (map[string]string, error)
+	// verifyCommit returns the commit hash of the revision
+	verifyCommit(git git, rev string) (string, error)
+	// hasRef reports whether the full ref name (eg: refs/heads/master) exists
+	hasRef(git git, refName string) bool
+	// currentBranch returns the branch of HEAD, an error when HEAD is detached
+	currentBranch(git git) (string, error)
+	// symbolicRef returns the short name of the ref pointed by the symbolic ref
+	symbolicRef(git git, refName string) (string, error)
+	// upstream returns the upstream branch of the current branch (eg: origin/master)
+	upstream(git git) (string, error)
+	// hasRemoteRef reports whether a remote-tracking ref of the remote contains the commit,
+	// or whether there is any when the commit is empty
+	hasRemoteRef(git git, remote, commit string) bool
+	// run runs the git command
+	run(git git, args ...string) ([]byte, error)
+}
+
+// Runner runs the git commands of the exec backend.
+// It can be replaced to run git elsewhere (eg: in a container), or by a fake in tests.
+type Runner interface {
+	// Run runs git with the arguments in the directory, and returns the standard output as is.
+	// On errors, it returns the output so far and a *GitError.
+	Run(ctx context.Context, dir string, args ...string) ([]byte, error)
+}
+
+// newBackend returns the backend of the name, BackendAuto when empty.
+// The exec backend runs git by the runner, the git command when nil.
+func newBackend(name string, runner Runner) (backend, error) {
+	if runner == nil {
+		runner = commandRunner{}
+	}
+	switch name {
+	case "", BackendAuto:
+		if _, ok := runner.(commandRunner); ok {
+			if _, err := exec.LookPath("git"); err != nil {
+				return goBackend{}, nil
+			}
+		}
+		return execBackend{runner}, nil
+	case BackendExec:
+		return execBackend{runner}, nil
+	case BackendGo:
+		return goBackend{}, nil
+	}
+	return nil, errorf(ErrInvalidOption, "unknown backend: %s (valid: %s, %s, %s)", name, BackendAuto, BackendExec, BackendGo)
+}
+
+// execBackend runs the git command by the runner
+type execBackend struct {
+	runner Runner
+}
+
+// git rev-parse --is-inside-work-tree --is-bare-repository --absolute-git-dir HEAD --show-toplevel --show-superproject-working-tree
+//   => true
+//      false
+//      /path/to/repo/.git
+//      695895662d96bac8d94fd71dc9d2dec534c8e494
+//      /path/to/repo
+//      /path/to/superproject (no line when not inside a submodule)
+// git rev-parse stops at the first failing argument, HEAD without commits and --show-toplevel
+// in a bare repository, so the output is read as far as it goes.
+func (b execBackend) revParse(git git) repoInfo {
+	out, _ := b.run(git, "rev-parse", "--is-inside-work-tree", "--is-bare-repository", "--absolute-git-dir",
+		"HEAD", "--show-toplevel", "--show-superproject-working-tree")
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) < 3 {
+		// Not a git repository
+		return repoInfo{}
+	}
+	info := repoInfo{
+		inside: lines[0] == "true",
+		bare:   lines[1] == "true",
+		gitDir: lines[2],
+	}
+	if len(lines) < 4 || !commitHashRegexp.MatchString(lines[3]) {
+		if info.inside {
+			// No commits yet
+			info.topDir, _ = git.exec("rev-parse", "--show-toplevel")
+		}
+		return info
+	}
+	info.head = lines[3]
+	if len(lines) > 4 {
+		info.topDir = lines[4]
+	}
+	if len(lines) > 5 {
+		info.superDir = lines[5]
+	}
+	return info
+}
+
+// git config -z [-f file] --get-regexp .
+//   => key1\nvalue1\0key2\nvalue2\0
+// All the config is read at once instead of a git config --get for each name.
+func (b execBackend) configs(git git, file string) (map[string]string, error) {
+	args := []string{"config", "-z"}
+	if file != "" {
+		args = append(args, "-f", file)
+	}
+	out, err := b.run(git, append(args, "--get-regexp", ".")...)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]string{}
+	for _, entry := range strings.Split(string(out), "\x00") {
+		if entry == "" {
+			continue
+		}
+		key, value, found := strings.Cut(entry, "\n")
+		if !found {
+			// eg: [gh-open] requirepushed
+			value = "true"
+		}
+		// The last value wins as git config --get
+		values[key] = value
+	}
+	return values, nil
+}
+
+// git rev-parse --verify --quiet <rev>^{commit}
+//   => 695895662d96bac8d94fd71dc9d2dec534c8e494
+func (b execBackend) verifyCommit(git git, rev string) (string, error) {
+	return git.exec("rev-parse", "--verify", "--quiet", rev+"^{commit}")
+}
+
+// git show-ref --verify --quiet <refname>
+func (b execBackend) hasRef(git git, refName string) bool {
+	_, err := git.exec("show-ref", "--verify", "--quiet", refName)
+	return err == nil
+}
+
+// git symbolic-ref --quiet --short HEAD
+//   => master (error when HEAD is detached)
+func (b execBackend) currentBranch(git git) (string, error) {
+	return git.exec("symbolic-ref", "--quiet", "--short", "HEAD")
+}
+
+// git symbolic-ref --short <refname>
+//   => origin/master
+func (b execBackend) symbolicRef(git git, refName string) (string, error) {
+	return git.exec("symbolic-ref", "--short", refName)
+}
+
+// git rev-parse --abbrev-ref --symbolic-full-name @{upstream}
+//   => origin/master
+func (b execBackend) upstream(git git) (string, error) {
+	return git.exec("rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}")
+}
+
+// git for-each-ref --count=1 --format=%(refname) [--contains <commit>] refs/remotes/<remote>/
+//   => refs/remotes/origin/master (empty when there is no such ref)
+func (b execBackend) hasRemoteRef(git git, remote, commit string) bool {
+	args := []string{"for-each-ref", "--count=1", "--format=%(refname)"}
+	if commit != "" {
+		args = append(args, "--contains", commit)
+	}
+	args = append(args, "refs/remotes/"+remote+"/")
+	ref, err := git.exec(args...)
+	return err == nil && ref != ""
+}
+
+// run returns the output as is (eg: file contents), and the output so far on errors
+func (b execBackend) run(git git, args ...string) ([]byte, error) {
+	runner := b.runner
+	if runner == nil {
+		runner = commandRunner{}
+	}
+	return runner.Run(git.ctx, git.dir, args...)
+}
+
+// commandRunner runs the git command, each one times out after gitTimeout
+type commandRunner struct{}
+
+func (commandRunner) Run(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, gitTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = gitEnv(args)
+	out, err := cmd.Output()
+	gitProcesses.Add(1)
+
+	if err != nil {
+		gitErr := &GitError{Args: args, Err: err}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			gitErr.Stderr = strings.TrimSpace(string(exitErr.Stderr))
+		}
+		if ctx.Err() != nil {
+			// Killed by the timeout or the cancel
+			gitErr.Err = ctx.Err()
+		}
+		return out, gitErr
+	}
+
+	return out, nil
+}
@@ -0,0 +1,501 @@
+package ghopen
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var errNoObjects = errors.New("the go backend does not read the objects, install git or use --backend exec")
+
+// goBackend reads the files of the git directory (HEAD, refs, packed-refs and config) without the git command.
+// The objects are not read, so the commands which need them (eg: diff, ls-tree, describe) are not supported,
+// and the links are made without translating the lines or following renames.
+type goBackend struct{}
+
+// revParse finds the git directory as git does: .git (a directory, or a file of a linked worktree or a submodule)
+// in dir or a parent, the directory itself (a bare repository), or $GIT_DIR.
+func (b goBackend) revParse(git git) repoInfo {
+	info, ok := b.discover(git.dir)
+	if !ok {
+		return repoInfo{}
+	}
+	info.head, _ = b.resolveRef(info.gitDir, "HEAD")
+	if info.inside {
+		info.superDir = b.superproject(info.topDir)
+	}
+	return info
+}
+
+func (b goBackend) discover(dir string) (repoInfo, bool) {
+	if gitDir := os.Getenv("GIT_DIR"); gitDir != "" {
+		gitDir, _ = filepath.Abs(gitDir)
+		workTree := os.Getenv("GIT_WORK_TREE")
+		if workTree == "" {
+			workTree = b.configWorkTree(gitDir)
+		}
+		if workTree == "" {
+			if b.isBare(gitDir) {
+				return repoInfo{bare: true, gitDir: gitDir}, true
+			}
+			// git uses the current directory as the top of the work tree
+			workTree, _ = os.Getwd()
+		}
+		workTree, _ = filepath.Abs(workTree)
+		return repoInfo{inside: isWithin(workTree, dir), gitDir: gitDir, topDir: workTree}, true
+	}
+
+	for d := dir; ; d = filepath.Dir(d) {
+		dotGit := filepath.Join(d, ".git")
+		if gitDir, ok := readGitDir(dotGit); ok {
+			topDir := d
+			if workTree := b.configWorkTree(gitDir); workTree != "" {
+				topDir = workTree
+			}
+			return repoInfo{inside: isWithin(topDir, dir), gitDir: gitDir, topDir: topDir}, true
+		}
+		if isGitDir(d) {
+			// Inside the git directory of a work tree (eg: .git/refs), or a bare repository
+			bare := filepath.Base(d) != ".git" && !strings.Contains(filepath.ToSlash(d), "/.git/") && b.isBare(d)
+			return repoInfo{bare: bare, gitDir: d}, true
+		}
+		if filepath.Dir(d) == d {
+			return repoInfo{}, false
+		}
+	}
+}
+
+// readGitDir returns the git directory of the .git directory or file
+//   gitdir: ../.git/modules/sub => /path/to/.git/modules/sub
+func readGitDir(dotGit string) (string, bool) {
+	if isDir(dotGit) {
+		return dotGit, isGitDir(dotGit)
+	}
+	content, err := os.ReadFile(dotGit)
+	if err != nil {
+		return "", false
+	}
+	gitDir, found := strings.CutPrefix(strings.TrimSpace(string(content)), "gitdir: ")
+	if !found {
+		return "", false
+	}
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(filepath.Dir(dotGit), gitDir)
+	}
+	return filepath.Clean(gitDir), isGitDir(gitDir)
+}
+
+// isGitDir reports whether dir looks like a git directory, as git does by HEAD and objects
+// (a linked worktree has commondir instead of objects)
+func isGitDir(dir string) bool {
+	return isFile(filepath.Join(dir, "HEAD")) &&
+		(isDir(filepath.Join(dir, "objects")) || isFile(filepath.Join(dir, "commondir")))
+}
+
+// isWithin reports whether path is dir or inside it
+func isWithin(dir, path string) bool {
+	rel, err := relativePath(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// commonDir returns the directory of the refs and the config shared by the linked worktrees
+func commonDir(gitDir string) string {
+	content, err := os.ReadFile(filepath.Join(gitDir, "commondir"))
+	if err != nil {
+		return gitDir
+	}
+	dir := strings.TrimSpace(string(content))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(gitDir, dir)
+	}
+	return filepath.Clean(dir)
+}
+
+func (b goBackend) isBare(gitDir string) bool {
+	values, _ := parseConfigFile(filepath.Join(commonDir(gitDir), "config"))
+	return values["core.bare"] == "true"
+}
+
+// configWorkTree returns core.worktree, relative to the git directory
+func (b goBackend) configWorkTree(gitDir string) string {
+	values, _ := parseConfigFile(filepath.Join(commonDir(gitDir), "config"))
+	workTree := values["core.worktree"]
+	if workTree != "" && !filepath.IsAbs(workTree) {
+		workTree = filepath.Join(gitDir, workTree)
+	}
+	return workTree
+}
+
+// superproject returns the top directory of the superproject which has topDir as a submodule in .gitmodules.
+// (git rev-parse --show-superproject-working-tree reads the gitlink of the index instead)
+func (b goBackend) superproject(topDir string) string {
+	if filepath.Dir(topDir) == topDir {
+		return ""
+	}
+	info, ok := b.discover(filepath.Dir(topDir))
+	if !ok || !info.inside {
+		return ""
+	}
+	subPath, err := relativePath(info.topDir, topDir)
+	if err != nil {
+		return ""
+	}
+	values, _ := parseConfigFile(filepath.Join(info.topDir, ".gitmodules"))
+	for key, path := range values {
+		if strings.HasPrefix(key, "submodule.") && strings.HasSuffix(key, ".path") && path == filepath.ToSlash(subPath) {
+			return info.topDir
+		}
+	}
+	return ""
+}
+
+// configs reads the config files in the order of git: system, global, repository and worktree
+func (b goBackend) configs(git git, file string) (map[string]string, error) {
+	if file != "" {
+		return parseConfigFile(filepath.Join(git.dir, file))
+	}
+
+	var files []string
+	if os.Getenv("GIT_CONFIG_NOSYSTEM") == "" {
+		files = append(files, "/etc/gitconfig")
+	}
+	if global := os.Getenv("GIT_CONFIG_GLOBAL"); global != "" {
+		files = append(files, global)
+	} else {
+		xdg := os.Getenv("XDG_CONFIG_HOME")
+		home, _ := os.UserHomeDir()
+		if xdg == "" && home != "" {
+			xdg = filepath.Join(home, ".config")
+		}
+		if xdg != "" {
+			files = append(files, filepath.Join(xdg, "git", "config"))
+		}
+		if home != "" {
+			files = append(files, filepath.Join(home, ".gitconfig"))
+		}
+	}
+	if git.gitDir != "" {
+		files = append(files, filepath.Join(commonDir(git.gitDir), "config"))
+	}
+
+	values := map[string]string{}
+	for _, f := range files {
+		fileValues, _ := parseConfigFile(f)
+		for k, v := range fileValues {
+			values[k] = v
+		}
+	}
+	if git.gitDir != "" && values["extensions.worktreeconfig"] == "true" {
+		fileValues, _ := parseConfigFile(filepath.Join(git.gitDir, "config.worktree"))
+		for k, v := range fileValues {
+			values[k] = v
+		}
+	}
+	return values, nil
+}
+
+func (b goBackend) verifyCommit(git git, rev string) (string, error) {
+	if commitHashRegexp.MatchString(rev) {
+		// The object is not looked up
+		return rev, nil
+	}
+	if rev == "HEAD" {
+		return b.resolveRef(git.gitDir, "HEAD")
+	}
+	// The order of git rev-parse (see gitrevisions)
+	for _, refName := range []string{rev, "refs/" + rev, "refs/tags/" + rev, "refs/heads/" + rev, "refs/remotes/" + rev, "refs/remotes/" + rev + "/HEAD"} {
+		if !strings.HasPrefix(refName, "refs/") {
+			continue
+		}
+		hash, err := b.resolveRef(git.gitDir, refName)
+		if err == nil {
+			return hash, nil
+		}
+	}
+	return "", errorf(ErrUnknownRevision, "unknown revision: %s", rev)
+}
+
+func (b goBackend) hasRef(git git, refName string) bool {
+	_, err := b.resolveRef(git.gitDir, refName)
+	return err == nil
+}
+
+func (b goBackend) currentBranch(git git) (string, error) {
+	target, err := b.readSymbolicRef(git.gitDir, "HEAD")
+	if err != nil {
+		return "", err
+	}
+	branch, found := strings.CutPrefix(target, "refs/heads/")
+	if !found {
+		return "", errors.New("HEAD is not a branch")
+	}
+	return branch, nil
+}
+
+func (b goBackend) symbolicRef(git git, refName string) (string, error) {
+	target, err := b.readSymbolicRef(git.gitDir, refName)
+	if err != nil {
+		return "", err
+	}
+	for _, prefix := range []string{"refs/heads/", "refs/tags/", "refs/remotes/"} {
+		if short, found := strings.CutPrefix(target, prefix); found {
+			return short, nil
+		}
+	}
+	return target, nil
+}
+
+// upstream returns the upstream from branch.<name>.remote and branch.<name>.merge,
+// assuming the default fetch refspec (refs/heads/* => refs/remotes/<remote>/*)
+func (b goBackend) upstream(git git) (string, error) {
+	branch, err := b.currentBranch(git)
+	if err != nil {
+		return "", err
+	}
+	remote := git.getConfig("branch."+branch+".remote", "")
+	merge := git.getConfig("branch."+branch+".merge", "")
+	if remote == "" || merge == "" {
+		return "", errors.New("no upstream configured for branch " + branch)
+	}
+	merge = strings.TrimPrefix(merge, "refs/heads/")
+	if remote == "." {
+		return merge, nil
+	}
+	return remote + "/" + merge, nil
+}
+
+// hasRemoteRef reports whether the remote has any remote-tracking ref, or one pointing at the commit.
+// Without reading the objects the history is not walked, so a commit behind the refs is taken as not pushed.
+func (b goBackend) hasRemoteRef(git git, remote, commit string) bool {
+	refs := b.listRefs(git.gitDir, "refs/remotes/"+remote+"/")
+	if commit == "" {
+		return len(refs) > 0
+	}
+	for _, ref := range refs {
+		if hash, err := b.resolveRef(git.gitDir, ref); err == nil && hash == commit {
+			return true
+		}
+	}
+	return false
+}
+
+func (b goBackend) run(git git, args ...string) ([]byte, error) {
+	return nil, &GitError{Args: args, Err: errNoObjects}
+}
+
+// readSymbolicRef returns the ref pointed by the symbolic ref (eg: HEAD => refs/heads/master)
+func (b goBackend) readSymbolicRef(gitDir, refName string) (string, error) {
+	content, err := os.ReadFile(b.refFile(gitDir, refName))
+	if err != nil {
+		return "", err
+	}
+	target, found := strings.CutPrefix(strings.TrimSpace(string(content)), "ref: ")
+	if !found {
+		return "", errors.New(refName + " is not a symbolic ref")
+	}
+	return target, nil
+}
+
+// refFile returns the file of the loose ref, HEAD is per worktree and the others are shared
+func (b goBackend) refFile(gitDir, refName string) string {
+	if refName == "HEAD" {
+		return filepath.Join(gitDir, "HEAD")
+	}
+	return filepath.Join(commonDir(gitDir), filepath.FromSlash(refName))
+}
+
+// resolveRef returns the commit hash of the ref, following symbolic refs.
+// The annotated tags are peeled only when packed-refs has the peeled hash.
+func (b goBackend) resolveRef(gitDir, refName string) (string, error) {
+	for depth := 0; depth < 5; depth++ {
+		content, err := os.ReadFile(b.refFile(gitDir, refName))
+		if err != nil {
+			ref, ok := b.packedRefs(gitDir)[refName]
+			if !ok {
+				return "", errorf(ErrUnknownRevision, "unknown revision: %s", refName)
+			}
+			if ref.peeled != "" {
+				return ref.peeled, nil
+			}
+			return ref.hash, nil
+		}
+		value := strings.TrimSpace(string(content))
+		target, found := strings.CutPrefix(value, "ref: ")
+		if !found {
+			if ref, ok := b.packedRefs(gitDir)[refName]; ok && ref.hash == value && ref.peeled != "" {
+				return ref.peeled, nil
+			}
+			return value, nil
+		}
+		refName = target
+	}
+	return "", errorf(ErrUnknownRevision, "too deep symbolic ref: %s", refName)
+}
+
+type packedRef struct {
+	hash   string
+	peeled string // The commit of an annotated tag
+}
+
+// packedRefs reads packed-refs
+//   695895662d96bac8d94fd71dc9d2dec534c8e494 refs/tags/v1.0.0
+//   ^4c9f3fd0e1a2ad4aa0d9cbbbb7d3a49e0e4fbb4b
+func (b goBackend) packedRefs(gitDir string) map[string]packedRef {
+	refs := map[string]packedRef{}
+	content, err := os.ReadFile(filepath.Join(commonDir(gitDir), "packed-refs"))
+	if err != nil {
+		return refs
+	}
+	var last string
+	for _, line := range strings.Split(string(content), "\n") {
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+		case strings.HasPrefix(line, "^"):
+			if ref, ok := refs[last]; ok {
+				ref.peeled = strings.TrimPrefix(line, "^")
+				refs[last] = ref
+			}
+		default:
+			hash, name, found := strings.Cut(line, " ")
+			if found {
+				refs[name] = packedRef{hash: hash}
+				last = name
+			}
+		}
+	}
+	return refs
+}
+
+// listRefs returns the loose and packed refs under the prefix (eg: refs/remotes/origin/)
+func (b goBackend) listRefs(gitDir, prefix string) []string {
+	seen := map[string]bool{}
+	var refs []string
+	root := commonDir(gitDir)
+	filepath.Walk(filepath.Join(root, filepath.FromSlash(prefix)), func(path string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			rel, _ := filepath.Rel(root, path)
+			seen[filepath.ToSlash(rel)] = true
+			refs = append(refs, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	for name := range b.packedRefs(gitDir) {
+		if strings.HasPrefix(name, prefix) && !seen[name] {
+			refs = append(refs, name)
+		}
+	}
+	return refs
+}
+
+// parseConfigFile parses the config file of git, keyed as git config --get-regexp prints the names
+//   [remote "origin"]
+//   	url = git@github.com:inouet/gh-open.git => remote.origin.url
+func parseConfigFile(file string) (map[string]string, error) {
+	values := map[string]string{}
+	err := parseConfig(file, values, 0)
+	return values, err
+}
+
+func parseConfig(file string, values map[string]string, depth int) error {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	section := ""
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		// Continued lines
+		for strings.HasSuffix(line, `\`) && !strings.HasSuffix(line, `\\`) && scanner.Scan() {
+			line = strings.TrimSuffix(line, `\`) + scanner.Text()
+		}
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if line[0] == '[' {
+			section = parseConfigSection(line)
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		if found {
+			value = parseConfigValue(value)
+		} else {
+			key = strings.ToLower(strings.TrimSpace(stripConfigComment(line)))
+			value = "true"
+		}
+		name := section + "." + key
+		if name == "include.path" && depth < 10 {
+			path := value
+			if strings.HasPrefix(path, "~/") {
+				home, _ := os.UserHomeDir()
+				path = filepath.Join(home, path[2:])
+			} else if !filepath.IsAbs(path) {
+				path = filepath.Join(filepath.Dir(file), path)
+			}
+			parseConfig(path, values, depth+1)
+			continue
+		}
+		values[name] = value
+	}
+	return scanner.Err()
+}
+
+// parseConfigSection returns the section of the header
+//   [remote "origin"] => remote.origin
+//   [branch.Main] => branch.main (deprecated syntax, lower case)
+func parseConfigSection(line string) string {
+	header := strings.TrimPrefix(line, "[")
+	if i := strings.LastIndex(header, "]"); i >= 0 {
+		header = header[:i]
+	}
+	name, sub, found := strings.Cut(header, " ")
+	if !found {
+		return strings.ToLower(header)
+	}
+	sub = strings.TrimSpace(sub)
+	sub = strings.TrimSuffix(strings.TrimPrefix(sub, `"`), `"`)
+	sub = strings.NewReplacer(`\\`, `\`, `\"`, `"`).Replace(sub)
+	return strings.ToLower(name) + "." + sub
+}
+
+// parseConfigValue unquotes the value and removes the comment
+func parseConfigValue(raw string) string {
+	var sb strings.Builder
+	quoted := false
+	raw = strings.TrimSpace(raw)
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case c == '\\' && i+1 < len(raw):
+			i++
+			switch raw[i] {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'b':
+				sb.WriteByte('\b')
+			default:
+				sb.WriteByte(raw[i])
+			}
+		case (c == '#' || c == ';') && !quoted:
+			return strings.TrimSpace(sb.String())
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+func stripConfigComment(s string) string {
+	if i := strings.IndexAny(s, "#;"); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
@@ -0,0 +1,215 @@
+package ghopen
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestGoBackend compares the go backend with the git command
+func TestGoBackend(t *testing.T) {
+	testDir := mkTempDir()
+	defer os.RemoveAll(testDir)
+
+	originDir := filepath.Join(testDir, "origin")
+	mkGitRepo(t, originDir, "")
+	runGit(t, originDir, "tag", "-a", "-m", "v1", "v1.0.0")
+	runGit(t, originDir, "tag", "light")
+
+	workDir := filepath.Join(testDir, "work")
+	runGit(t, testDir, "clone", "-q", originDir, workDir)
+	runGit(t, workDir, "remote", "set-url", "origin", "https://github.com/inouet/gh-open.git")
+	runGit(t, workDir, "checkout", "-q", "-b", "feature/x", "--track", "origin/master")
+	runGit(t, workDir, "commit", "-q", "--allow-empty", "-m", "local")
+	os.MkdirAll(filepath.Join(workDir, "docs"), 0777)
+	runGit(t, workDir, "config", "gh-open.urltype", "gitlab.com")
+
+	// Tags and remote-tracking branches in packed-refs, branches as loose refs
+	packedDir := filepath.Join(testDir, "packed")
+	runGit(t, testDir, "clone", "-q", originDir, packedDir)
+	runGit(t, packedDir, "pack-refs", "--all")
+	runGit(t, packedDir, "commit", "-q", "--allow-empty", "-m", "local")
+
+	bareDir := filepath.Join(testDir, "bare.git")
+	runGit(t, testDir, "clone", "-q", "--bare", originDir, bareDir)
+
+	worktreeDir := filepath.Join(testDir, "worktree")
+	runGit(t, workDir, "worktree", "add", "-q", "-b", "wt", worktreeDir, "master")
+
+	superDir := filepath.Join(testDir, "super")
+	mkGitRepo(t, superDir, "git@github.com:inouet/super.git")
+	runGit(t, superDir, "-c", "protocol.file.allow=always", "submodule", "add", "-q", originDir, "lib")
+
+	cases := map[string]struct {
+		dir  string
+		revs []string
+		refs []string
+	}{
+		"work-tree":     {dir: filepath.Join(workDir, "docs"), revs: []string{"HEAD", "master", "feature/x", "origin/master", "v1.0.0", "light", "origin"}, refs: []string{"refs/heads/feature/x", "refs/tags/v1.0.0", "refs/heads/none"}},
+		"packed-refs":   {dir: packedDir, revs: []string{"HEAD", "master", "origin/master", "v1.0.0", "light"}, refs: []string{"refs/tags/v1.0.0", "refs/remotes/origin/master"}},
+		"bare":          {dir: bareDir, revs: []string{"HEAD", "master", "v1.0.0"}, refs: []string{"refs/heads/master"}},
+		"worktree":      {dir: worktreeDir, revs: []string{"HEAD", "wt", "feature/x"}, refs: []string{"refs/heads/wt"}},
+		"submodule":     {dir: filepath.Join(superDir, "lib"), revs: []string{"HEAD", "origin/master"}},
+		"in-git-dir":    {dir: filepath.Join(workDir, ".git", "refs")},
+		"not-a-repo":    {dir: testDir},
+		"superproject":  {dir: superDir, revs: []string{"HEAD", "master"}},
+		"unknown-rev":   {dir: workDir, revs: []string{"nothing"}},
+		"remote-branch": {dir: workDir, revs: []string{"origin/HEAD"}},
+	}
+	for name, c := range cases {
+		execGit, err := newGit(context.Background(), execBackend{}, c.dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		goGit, err := newGit(context.Background(), goBackend{}, c.dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		gotInfo := []interface{}{evalSymlinks(goGit.dir), goGit.inside, goGit.bare, evalSymlinks(goGit.gitDir), goGit.head, evalSymlinks(goGit.superDir)}
+		wantInfo := []interface{}{evalSymlinks(execGit.dir), execGit.inside, execGit.bare, evalSymlinks(execGit.gitDir), execGit.head, evalSymlinks(execGit.superDir)}
+		if !reflect.DeepEqual(gotInfo, wantInfo) {
+			t.Errorf("%s: want %v, got %v\n", name, wantInfo, gotInfo)
+		}
+		if !execGit.inside && !execGit.bare {
+			continue
+		}
+
+		for _, rev := range c.revs {
+			want, wantErr := execGit.verifyCommit(rev)
+			got, gotErr := goGit.verifyCommit(rev)
+			if got != want || (gotErr == nil) != (wantErr == nil) {
+				t.Errorf("%s: verifyCommit(%s) want %s %v, got %s %v\n", name, rev, want, wantErr, got, gotErr)
+			}
+		}
+		for _, ref := range c.refs {
+			if goGit.hasRef(ref) != execGit.hasRef(ref) {
+				t.Errorf("%s: hasRef(%s) want %v\n", name, ref, execGit.hasRef(ref))
+			}
+		}
+
+		want, wantErr := execGit.getCurrentBranch()
+		got, gotErr := goGit.getCurrentBranch()
+		if got != want || (gotErr == nil) != (wantErr == nil) {
+			t.Errorf("%s: getCurrentBranch want %s %v, got %s %v\n", name, want, wantErr, got, gotErr)
+		}
+		want, wantErr = execGit.getUpstream()
+		got, gotErr = goGit.getUpstream()
+		if got != want || (gotErr == nil) != (wantErr == nil) {
+			t.Errorf("%s: getUpstream want %s %v, got %s %v\n", name, want, wantErr, got, gotErr)
+		}
+		want, wantErr = execGit.getRemoteHead(remoteName)
+		got, gotErr = goGit.getRemoteHead(remoteName)
+		if got != want || (gotErr == nil) != (wantErr == nil) {
+			t.Errorf("%s: getRemoteHead want %s %v, got %s %v\n", name, want, wantErr, got, gotErr)
+		}
+		if goGit.hasRemoteRef(remoteName, "") != execGit.hasRemoteRef(remoteName, "") {
+			t.Errorf("%s: hasRemoteRef want %v\n", name, execGit.hasRemoteRef(remoteName, ""))
+		}
+		// HEAD is never taken as pushed when it is not
+		if head, err := execGit.getCommitHash(); err == nil && !execGit.hasRemoteRef(remoteName, head) && goGit.hasRemoteRef(remoteName, head) {
+			t.Errorf("%s: hasRemoteRef(HEAD) want false\n", name)
+		}
+
+		for key, want := range execGit.getConfigs() {
+			if got := goGit.getConfig(key, "<none>"); got != want {
+				t.Errorf("%s: config %s want '%s', got '%s'\n", name, key, want, got)
+			}
+		}
+	}
+}
+
+func TestGoBackendResolve(t *testing.T) {
+	testDir := mkTempDir()
+	defer os.RemoveAll(testDir)
+
+	mkGitRepo(t, testDir, "git@github.com:inouet/gh-open.git")
+	head := runGit(t, testDir, "rev-parse", "HEAD")
+	runGit(t, testDir, "update-ref", "refs/remotes/origin/master", head)
+	runGit(t, testDir, "symbolic-ref", "refs/remotes/origin/HEAD", "refs/remotes/origin/master")
+	runGit(t, testDir, "branch", "-q", "--set-upstream-to=origin/master")
+	path := filepath.Join(testDir, "README.md")
+
+	cases := map[string]Options{
+		"commit":         {Lines: testLines(2, 3)},
+		"branch":         {Branch: "master", Lines: testLines(2, 0)},
+		"default-branch": {DefaultBranch: true},
+		"current-branch": {CurrentBranch: true},
+		"match":          {Match: "line2"},
+	}
+	for name, opts := range cases {
+		want, err := Resolver{Backend: BackendExec}.Resolve(context.Background(), path, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The lines are not translated without a warning, as documented
+		var warnings []string
+		got, err := Resolver{Backend: BackendGo, Warn: func(msg string) { warnings = append(warnings, msg) }}.Resolve(context.Background(), path, opts)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(warnings) != 0 {
+			t.Errorf("%s want no warnings, got %v\n", name, warnings)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s want %+v, got %+v\n", name, want, got)
+		}
+	}
+
+	// An unpushed HEAD fails by --require-pushed as with the git command
+	runGit(t, testDir, "commit", "-q", "--allow-empty", "-m", "local")
+	_, err := Resolver{Backend: BackendGo}.Resolve(context.Background(), path, Options{RequirePushed: true})
+	if !errors.Is(err, ErrNotPushed) {
+		t.Errorf("want ErrNotPushed, got %v\n", err)
+	}
+
+	_, err = Resolver{Backend: "svn"}.Resolve(context.Background(), path, Options{})
+	if err == nil {
+		t.Errorf("want the error of the unknown backend\n")
+	}
+}
+
+func TestParseConfigFile(t *testing.T) {
+	testDir := mkTempDir()
+	defer os.RemoveAll(testDir)
+
+	os.WriteFile(filepath.Join(testDir, "included"), []byte("[gh-open]\n\tprotocol = http\n"), 0666)
+	config := `# comment
+[core]
+	bare = false
+[remote "origin"]
+	url = git@github.com:inouet/gh-open.git ; comment
+[branch "Feature/X"]
+	Merge = "refs/heads/Feature/X"
+[gh-open]
+	requirePushed
+	urltype = "github.com" # comment
+	note = "a;b#c \"quoted\""
+[include]
+	path = included
+[Old.Style]
+	key = value
+`
+	os.WriteFile(filepath.Join(testDir, "config"), []byte(config), 0666)
+
+	got, err := parseConfigFile(filepath.Join(testDir, "config"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"core.bare":              "false",
+		"remote.origin.url":      "git@github.com:inouet/gh-open.git",
+		"branch.Feature/X.merge": "refs/heads/Feature/X",
+		"gh-open.requirepushed":  "true",
+		"gh-open.urltype":        "github.com",
+		"gh-open.note":           `a;b#c "quoted"`,
+		"gh-open.protocol":       "http",
+		"old.style.key":          "value",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v\n", want, got)
+	}
+}
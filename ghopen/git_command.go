@@ -0,0 +1,395 @@
+package ghopen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// gitProcesses counts the git processes, for the benchmarks
+var gitProcesses atomic.Int64
+
+// gitTimeout is the timeout of each git command (eg: ls-remote waiting for the network)
+const gitTimeout = 30 * time.Second
+
+// git is a struct
+type git struct {
+	ctx     context.Context // Cancels the git commands
+	backend backend
+	dir     string // Top directory of the work tree, or the git directory of a bare repository
+	gitDir  string
+	bare    bool
+
+	inside   bool      // Inside the work tree
+	head     string    // HEAD commit when the repository has one
+	superDir string    // Top directory of the superproject when this is a submodule
+	config   *gitCache // Lazily loaded git config
+}
+
+// gitCache is the git config read at once by getConfigs
+type gitCache struct {
+	loaded bool
+	values map[string]string
+}
+
+// newGit returns the git of the repository of dir, read by the backend (auto selected when nil)
+func newGit(ctx context.Context, b backend, dir string) (*git, error) {
+	if !isDir(dir) {
+		return nil, errorf(ErrNotExist, "%s: no such directory", dir)
+	}
+	if b == nil {
+		b, _ = newBackend(BackendAuto, nil)
+	}
+	g := &git{ctx: ctx, backend: b, dir: dir, config: &gitCache{}}
+
+	info := b.revParse(*g)
+	g.gitDir = info.gitDir
+	if info.inside {
+		g.dir = info.topDir
+		g.inside = true
+		g.head = info.head
+		g.superDir = info.superDir
+	} else if info.bare {
+		g.dir = info.gitDir
+		g.bare = true
+		g.head = info.head
+	}
+	return g, nil
+}
+
+// repoInfo is the repository found by backend.revParse
+type repoInfo struct {
+	inside   bool
+	bare     bool
+	gitDir   string
+	head     string
+	topDir   string
+	superDir string
+}
+
+// git config --get remote.origin.url
+//   => git@github.com:inouet/gh-open.git
+func (git git) getRemoteOriginURL() (string, error) {
+	url := git.getConfig("remote.origin.url", "")
+	if url == "" {
+		return "", errors.New("remote.origin.url is not set")
+	}
+	return url, nil
+}
+
+// git rev-parse HEAD
+//   => 695895662d96bac8d94fd71dc9d2dec534c8e494
+func (git git) getCommitHash() (string, error) {
+	if git.head != "" {
+		return git.head, nil
+	}
+	return git.backend.verifyCommit(git, "HEAD")
+}
+
+// git rev-parse --abbrev-ref --symbolic-full-name @{upstream}
+//   => origin/master
+func (git git) getUpstream() (string, error) {
+	return git.backend.upstream(git)
+}
+
+// git merge-base <commit1> <commit2>
+//   => 695895662d96bac8d94fd71dc9d2dec534c8e494
+func (git git) getMergeBase(commit1, commit2 string) (string, error) {
+	return git.exec("merge-base", commit1, commit2)
+}
+
+// git for-each-ref --count=1 --format=%(refname) [--contains <commit>] refs/remotes/<remote>/
+//   => refs/remotes/origin/master (empty when there is no such ref)
+func (git git) hasRemoteRef(remote, commit string) bool {
+	return git.backend.hasRemoteRef(git, remote, commit)
+}
+
+// git diff -U0 -M <ref> -- paths
+//   => unified diff from ref to the working tree
+func (git git) getDiff(ref string, paths ...string) (string, error) {
+	args := append([]string{"diff", "-U0", "-M", "--no-color", "--no-ext-diff", ref, "--"}, paths...)
+	return git.exec(args...)
+}
+
+// git diff -M --name-status -z <ref>
+//   => [R100 old/path new/path], [A new/path], ... (the paths are not quoted by -z)
+func (git git) getNameStatus(ref string) ([][]string, error) {
+	out, err := git.output("diff", "-M", "--name-status", "--no-color", "-z", ref)
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00")
+	var entries [][]string
+	for i := 0; i < len(fields); {
+		// Renames and copies have the old and the new paths
+		n := 2
+		if strings.HasPrefix(fields[i], "R") || strings.HasPrefix(fields[i], "C") {
+			n = 3
+		}
+		if i+n > len(fields) {
+			break
+		}
+		entries = append(entries, fields[i:i+n])
+		i += n
+	}
+	return entries, nil
+}
+
+// git rev-parse --verify --quiet <ref>^{commit}
+//   => 695895662d96bac8d94fd71dc9d2dec534c8e494
+func (git git) verifyCommit(ref string) (string, error) {
+	return git.backend.verifyCommit(git, ref)
+}
+
+// git show-ref --verify --quiet <refname>
+func (git git) hasRef(refName string) bool {
+	return git.backend.hasRef(git, refName)
+}
+
+// git symbolic-ref --quiet --short HEAD
+//   => master (error when HEAD is detached)
+func (git git) getCurrentBranch() (string, error) {
+	return git.backend.currentBranch(git)
+}
+
+// git symbolic-ref --short refs/remotes/<remote>/HEAD
+//   => origin/master
+func (git git) getRemoteHead(remote string) (string, error) {
+	return git.backend.symbolicRef(git, "refs/remotes/"+remote+"/HEAD")
+}
+
+// git ls-remote --symref <remote> HEAD
+//   => ref: refs/heads/master	HEAD
+func (git git) getRemoteHeadSymref(remote string) (string, error) {
+	return git.lsRemote("--symref", remote, "HEAD")
+}
+
+// git ls-remote --heads <remote> refs/heads/<branch>
+//   => 695895662d96bac8d94fd71dc9d2dec534c8e494	refs/heads/master (empty when there is no such branch)
+func (git git) getRemoteBranch(remote, branch string) (string, error) {
+	return git.lsRemote("--heads", remote, "refs/heads/"+branch)
+}
+
+// lsRemote runs git ls-remote, and ssh in the batch mode unless the user configures the ssh command,
+// because ssh would wait for the passphrase until the timeout (eg: in serve).
+// GIT_SSH_COMMAND overrides core.sshCommand, which overrides GIT_SSH.
+func (git git) lsRemote(args ...string) (string, error) {
+	args = append([]string{"ls-remote"}, args...)
+	if os.Getenv("GIT_SSH") == "" && os.Getenv("GIT_SSH_COMMAND") == "" && git.getConfig("core.sshCommand", "") == "" {
+		args = append([]string{"-c", "core.sshCommand=ssh -o BatchMode=yes"}, args...)
+	}
+	return git.exec(args...)
+}
+
+// git ls-files --error-unmatch -- path
+func (git git) isTracked(path string) bool {
+	_, err := git.exec("ls-files", "--error-unmatch", "--", path)
+	return err == nil
+}
+
+// git check-ignore --quiet -- path
+func (git git) isIgnored(path string) bool {
+	_, err := git.exec("check-ignore", "--quiet", "--", path)
+	return err == nil
+}
+
+// git for-each-ref --points-at <commit> --sort=-taggerdate --format=%(objecttype) %(refname:short) refs/tags/
+//   => v1.0.0, the newest annotated tag pointing at the commit, or a lightweight one as describe --exact-match does
+//      (empty when there is none, which describe reports by a message we cannot tell from the other errors)
+func (git git) tagPointingAt(commit string) (string, error) {
+	out, err := git.exec("for-each-ref", "--points-at", commit, "--sort=-taggerdate", "--format=%(objecttype) %(refname:short)", "refs/tags/")
+	if err != nil || out == "" {
+		return "", err
+	}
+	lines := strings.Split(out, "\n")
+	for _, line := range lines {
+		if tag, found := strings.CutPrefix(line, "tag "); found {
+			return tag, nil
+		}
+	}
+	_, tag, _ := strings.Cut(lines[0], " ")
+	return tag, nil
+}
+
+// git for-each-ref --contains <commit> --sort=taggerdate --format=%(objecttype) %(refname:short) refs/tags/
+//   => v1.0.0, the first annotated tag containing the commit (describe --contains takes the lightweight tags too)
+func (git git) nearestAnnotatedTag(commit string) (string, error) {
+	out, err := git.exec("for-each-ref", "--contains", commit, "--sort=taggerdate", "--format=%(objecttype) %(refname:short)", "refs/tags/")
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if tag, found := strings.CutPrefix(line, "tag "); found {
+			return tag, nil
+		}
+	}
+	return "", nil
+}
+
+// git cat-file blob <ref>:<path>
+//   => contents of the file at ref (an error for a directory)
+func (git git) getFileContent(ref, path string) ([]byte, error) {
+	return git.output("cat-file", "blob", ref+":"+path)
+}
+
+// git ls-tree <ref> -- path
+//   => 100644 blob 4c9f3fd0e1a2ad4aa0d9cbbbb7d3a49e0e4fbb4b	path
+func (git git) existsAt(ref, path string) (bool, error) {
+	out, err := git.exec("ls-tree", ref, "--", path)
+	return err == nil && out != "", err
+}
+
+// isInsideWorkTree reports whether the directory is in a work tree, as git rev-parse --is-inside-work-tree
+func (git git) isInsideWorkTree() bool {
+	return git.inside
+}
+
+// git rev-parse --show-superproject-working-tree
+//   => /path/to/superproject (empty when not inside a submodule)
+func (git git) getSuperprojectDir() (string, error) {
+	return git.superDir, nil
+}
+
+// git ls-tree HEAD -- path
+//   => 160000 commit 695895662d96bac8d94fd71dc9d2dec534c8e494	path
+func (git git) getGitlinkHash(path string) (string, error) {
+	out, err := git.exec("ls-tree", "HEAD", "--", path)
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Fields(out)
+	if len(fields) < 3 || fields[1] != "commit" {
+		return "", fmt.Errorf("not a submodule: %s", path)
+	}
+	return fields[2], nil
+}
+
+// git config -f .gitmodules --get-regexp .
+//   => submodule.<name>.path <path>
+//      submodule.<name>.url <url>
+func (git git) getSubmodules() ([]submodule, error) {
+	if !isFile(filepath.Join(git.dir, ".gitmodules")) {
+		return nil, nil
+	}
+	values, err := git.backend.configs(git, ".gitmodules")
+	if err != nil {
+		return nil, err
+	}
+	var submodules []submodule
+	for key, path := range values {
+		if !strings.HasPrefix(key, "submodule.") || !strings.HasSuffix(key, ".path") {
+			continue
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(key, "submodule."), ".path")
+		submodules = append(submodules, submodule{name: name, path: path, url: values["submodule."+name+".url"]})
+	}
+	sort.Slice(submodules, func(i, j int) bool { return submodules[i].path < submodules[j].path })
+	return submodules, nil
+}
+
+// getConfigs returns the config, which is read at once instead of a git config --get for each name
+func (git git) getConfigs() map[string]string {
+	if git.config == nil {
+		git.config = &gitCache{}
+	}
+	if git.config.loaded {
+		return git.config.values
+	}
+	git.config.loaded = true
+	git.config.values, _ = git.backend.configs(git, "")
+	if git.config.values == nil {
+		git.config.values = map[string]string{}
+	}
+	return git.config.values
+}
+
+// configKey normalizes the name as git config --get-regexp prints it.
+// The section and the key are case insensitive, the subsection is not.
+//   branch.Feature.Merge => branch.Feature.merge
+func configKey(name string) string {
+	first := strings.Index(name, ".")
+	last := strings.LastIndex(name, ".")
+	if first < 0 {
+		return strings.ToLower(name)
+	}
+	return strings.ToLower(name[:first]) + name[first:last] + strings.ToLower(name[last:])
+}
+
+// git config name
+func (git git) getConfig(name, defaultValue string) string {
+	configValue, ok := git.getConfigs()[configKey(name)]
+	if !ok {
+		return defaultValue
+	}
+	return configValue
+}
+
+// git config --bool name
+func (git git) getConfigBool(name string, defaultValue bool) bool {
+	configValue, ok := git.getConfigs()[configKey(name)]
+	if !ok {
+		return defaultValue
+	}
+	switch strings.ToLower(configValue) {
+	case "true", "yes", "on", "1":
+		return true
+	case "false", "no", "off", "0", "":
+		return false
+	}
+	return defaultValue
+}
+
+func (git git) exec(args ...string) (string, error) {
+	out, err := git.output(args...)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// output returns the output as is (eg: file contents), and the output so far on errors
+func (git git) output(args ...string) ([]byte, error) {
+	return git.backend.run(git, args...)
+}
+
+// gitEnv returns the environment of the git command with GIT_DIR and GIT_WORK_TREE made absolute,
+// because git resolves relative ones against cmd.Dir instead of our working directory.
+// ls-remote does not prompt for the credentials, which would wait until the timeout (eg: in serve).
+func gitEnv(args []string) []string {
+	env := os.Environ()
+	for i, kv := range env {
+		for _, name := range []string{"GIT_DIR", "GIT_WORK_TREE"} {
+			value := strings.TrimPrefix(kv, name+"=")
+			if value == kv || value == "" || filepath.IsAbs(value) {
+				continue
+			}
+			absValue, err := filepath.Abs(value)
+			if err == nil {
+				env[i] = name + "=" + absValue
+			}
+		}
+	}
+	if gitSubcommand(args) == "ls-remote" {
+		env = append(env, "GIT_TERMINAL_PROMPT=0")
+	}
+	return env
+}
+
+// gitSubcommand returns the subcommand after the -c options
+//   -c core.sshCommand=ssh ls-remote origin => ls-remote
+func gitSubcommand(args []string) string {
+	for i := 0; i < len(args); i++ {
+		if args[i] == "-c" {
+			i++
+			continue
+		}
+		return args[i]
+	}
+	return ""
+}
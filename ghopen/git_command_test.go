@@ -0,0 +1,152 @@
+package ghopen
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRevParse(t *testing.T) {
+	testDir := mkTempDir()
+	defer os.RemoveAll(testDir)
+
+	workDir := filepath.Join(testDir, "work")
+	mkGitRepo(t, workDir, "")
+	head := runGit(t, workDir, "rev-parse", "HEAD")
+	os.MkdirAll(filepath.Join(workDir, "sub"), 0777)
+	bareDir := filepath.Join(testDir, "bare.git")
+	runGit(t, testDir, "clone", "-q", "--bare", workDir, bareDir)
+	emptyDir := filepath.Join(testDir, "empty")
+	os.MkdirAll(emptyDir, 0777)
+	runGit(t, emptyDir, "init", "-q")
+	noRepoDir := filepath.Join(testDir, "norepo")
+	os.MkdirAll(noRepoDir, 0777)
+
+	cases := map[string]struct {
+		dir      string
+		wantDir  string
+		inside   bool
+		bare     bool
+		wantHead string
+	}{
+		"work-tree":   {dir: filepath.Join(workDir, "sub"), wantDir: workDir, inside: true, wantHead: head},
+		"bare":        {dir: bareDir, wantDir: bareDir, bare: true, wantHead: head},
+		"no-commits":  {dir: emptyDir, wantDir: emptyDir, inside: true},
+		"not-in-repo": {dir: noRepoDir, wantDir: noRepoDir},
+	}
+	for name, c := range cases {
+		git, err := newGit(context.Background(), nil, c.dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if evalSymlinks(git.dir) != evalSymlinks(c.wantDir) || git.isInsideWorkTree() != c.inside || git.bare != c.bare || git.head != c.wantHead {
+			t.Errorf("%s want %s %v %v %s, got %s %v %v %s\n", name, c.wantDir, c.inside, c.bare, c.wantHead, git.dir, git.inside, git.bare, git.head)
+		}
+	}
+}
+
+func TestGetConfig(t *testing.T) {
+	testDir := mkTempDir()
+	defer os.RemoveAll(testDir)
+
+	mkGitRepo(t, testDir, "https://github.com/inouet/gh-open.git")
+	runGit(t, testDir, "config", "branch.Feature/X.merge", "refs/heads/Feature/X")
+	runGit(t, testDir, "config", "gh-open.requirePushed", "yes")
+	runGit(t, testDir, "config", "--add", "gh-open.urltype", "gitlab.com")
+	runGit(t, testDir, "config", "--add", "gh-open.urltype", "github.com")
+
+	git, _ := newGit(context.Background(), nil, testDir)
+	cases := []struct {
+		name string
+		want string
+	}{
+		{name: "remote.origin.url", want: "https://github.com/inouet/gh-open.git"},
+		{name: "branch.Feature/X.MERGE", want: "refs/heads/Feature/X"},
+		{name: "branch.feature/x.merge", want: "none"},
+		{name: "gh-open.urltype", want: "github.com"},
+		{name: "gh-open.protocol", want: "none"},
+	}
+	for _, c := range cases {
+		got := git.getConfig(c.name, "none")
+		if got != c.want {
+			t.Errorf("%s want '%s', got '%s'\n", c.name, c.want, got)
+		}
+	}
+	if !git.getConfigBool("gh-open.requirepushed", false) {
+		t.Errorf("want gh-open.requirepushed true\n")
+	}
+}
+
+func TestGitTimeout(t *testing.T) {
+	testDir := mkTempDir()
+	defer os.RemoveAll(testDir)
+
+	mkGitRepo(t, testDir, "")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+
+	g := git{ctx: ctx, backend: execBackend{}, dir: testDir}
+	_, err := g.exec("status")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want context.DeadlineExceeded, got %v\n", err)
+	}
+}
+
+func TestGitEnv(t *testing.T) {
+	env := gitEnv([]string{"-c", "core.sshCommand=ssh -o BatchMode=yes", "ls-remote", "--heads", "origin"})
+	if !slices.Contains(env, "GIT_TERMINAL_PROMPT=0") {
+		t.Errorf("ls-remote want GIT_TERMINAL_PROMPT=0\n")
+	}
+	if env := gitEnv([]string{"status"}); slices.Contains(env, "GIT_TERMINAL_PROMPT=0") {
+		t.Errorf("status want the prompt as is\n")
+	}
+}
+
+func TestLsRemoteSSHCommand(t *testing.T) {
+	batchMode := "-c core.sshCommand=ssh -o BatchMode=yes ls-remote --heads origin refs/heads/master"
+	cases := map[string]struct {
+		env    map[string]string
+		config map[string]string
+		want   string
+	}{
+		"unset": {
+			want: batchMode,
+		},
+		"git-ssh-command": {
+			env:  map[string]string{"GIT_SSH_COMMAND": "ssh -i key"},
+			want: "ls-remote --heads origin refs/heads/master",
+		},
+		"git-ssh": {
+			env:  map[string]string{"GIT_SSH": "plink"},
+			want: "ls-remote --heads origin refs/heads/master",
+		},
+		"core-ssh-command": {
+			config: map[string]string{"core.sshcommand": "ssh -i key"},
+			want:   "ls-remote --heads origin refs/heads/master",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("GIT_SSH", "")
+			t.Setenv("GIT_SSH_COMMAND", "")
+			for k, v := range c.env {
+				t.Setenv(k, v)
+			}
+			runner := &fakeRunner{outputs: map[string]string{}}
+			g := git{
+				ctx:     context.Background(),
+				backend: execBackend{runner: runner},
+				config:  &gitCache{loaded: true, values: c.config},
+			}
+			g.getRemoteBranch("origin", "master")
+			if len(runner.calls) != 1 || runner.calls[0] != c.want {
+				t.Errorf("want '%s', got %q\n", c.want, runner.calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package ghopen
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var hunkHeaderRegexp = regexp.MustCompile(`^@@ -([0-9]+)(?:,([0-9]+))? \+([0-9]+)(?:,([0-9]+))? @@`)
+
+// diffHunk is a hunk header of a unified diff
+//   @@ -oldStart,oldLines +newStart,newLines @@
+type diffHunk struct {
+	oldStart int
+	oldLines int
+	newStart int
+	newLines int
+}
+
+// parseDiffHunks parses the hunk headers of `git diff -U0` output
+func parseDiffHunks(diff string) ([]diffHunk, error) {
+	var hunks []diffHunk
+	for _, line := range strings.Split(diff, "\n") {
+		if !strings.HasPrefix(line, "@@ ") {
+			continue
+		}
+		m := hunkHeaderRegexp.FindStringSubmatch(line)
+		if m == nil {
+			return nil, fmt.Errorf("invalid hunk header: %s", line)
+		}
+		h := diffHunk{oldLines: 1, newLines: 1}
+		h.oldStart, _ = strconv.Atoi(m[1])
+		if m[2] != "" {
+			h.oldLines, _ = strconv.Atoi(m[2])
+		}
+		h.newStart, _ = strconv.Atoi(m[3])
+		if m[4] != "" {
+			h.newLines, _ = strconv.Atoi(m[4])
+		}
+		hunks = append(hunks, h)
+	}
+	return hunks, nil
+}
+
+// mapLine maps line n of the new file to the line of the old file.
+// ok is false when the line does not exist in the old file (added or changed),
+// then the nearest line of the old file is returned.
+func mapLine(hunks []diffHunk, n int) (line int, ok bool) {
+	offset := 0
+	for _, h := range hunks {
+		// When newLines is 0, lines were only deleted after line newStart
+		if (h.newLines > 0 && n < h.newStart) || (h.newLines == 0 && n <= h.newStart) {
+			break
+		}
+		if n < h.newStart+h.newLines {
+			line = h.oldStart
+			if h.oldLines > 0 {
+				line += min(n-h.newStart, h.oldLines-1)
+			}
+			return max(line, 1), false
+		}
+		offset += h.oldLines - h.newLines
+	}
+	return n + offset, true
+}
+
+// localRef returns ref if it is a commit in the local repository.
+// The remote-tracking branch is preferred, as the local branch may have unpushed commits,
+// and -b may name a branch which only exists as a remote-tracking branch.
+// A full commit hash is returned as is, the git commands reading it fail when it is not fetched.
+func (r gitRemote) localRef(ref string) string {
+	if commitHashRegexp.MatchString(ref) {
+		return ref
+	}
+	if r.git.hasRef("refs/remotes/" + remoteName + "/" + ref) {
+		return remoteName + "/" + ref
+	}
+	if _, err := r.git.verifyCommit(ref); err == nil {
+		return ref
+	}
+	if _, err := r.git.verifyCommit(remoteName + "/" + ref); err == nil {
+		return remoteName + "/" + ref
+	}
+	return ""
+}
+
+// pathAtRef returns the path of the object at ref (localRef in the local repository, empty when not fetched),
+// following renames between ref and the working tree.
+func (r gitRemote) pathAtRef(ref, localRef string) (string, error) {
+	if r.path == "" {
+		return r.path, nil
+	}
+	treePath := filepath.ToSlash(r.path)
+	if r.git.bare {
+		// No working tree to follow the renames from, the path is in the tree at ref
+		if localRef == "" {
+			return r.path, nil
+		}
+		if exists, err := r.git.existsAt(localRef, treePath); err == nil && !exists {
+			return "", errorf(ErrNotExist, "%s does not exist at %s", r.path, ref)
+		}
+		return r.path, nil
+	}
+	if localRef == "" {
+		// The ref is not fetched (eg: -b <branch> only on the remote), the file is at least committed
+		return r.path, r.notCommitted(treePath)
+	}
+	if exists, _ := r.git.existsAt(localRef, treePath); exists {
+		return r.path, nil
+	}
+
+	nameStatus, err := r.git.getNameStatus(localRef)
+	if err != nil {
+		return r.path, nil
+	}
+	for _, fields := range nameStatus {
+		switch {
+		case len(fields) == 3 && strings.HasPrefix(fields[0], "R") && fields[2] == treePath:
+			return fields[1], nil
+		case len(fields) == 2 && fields[0] == "A" && fields[1] == treePath:
+			return "", errorf(ErrNotExist, "%s is a new file and has no remote counterpart at %s", r.path, ref)
+		}
+	}
+	if err := r.notCommitted(treePath); err != nil {
+		return "", err
+	}
+	return "", errorf(ErrNotExist, "%s does not exist at %s", r.path, ref)
+}
+
+// notCommitted returns the error of an ignored or untracked path, nil for a tracked one
+func (r gitRemote) notCommitted(treePath string) error {
+	switch {
+	case r.git.isIgnored(treePath):
+		return errorf(ErrNotExist, "%s is ignored by .gitignore and has no remote counterpart", r.path)
+	case !r.git.isTracked(treePath):
+		return errorf(ErrNotExist, "%s is untracked, git add, commit and push it first", r.path)
+	}
+	return nil
+}
+
+// mapLineRanges maps the lines of the working file to the lines of refPath at ref (localRef in the local repository)
+func (r gitRemote) mapLineRanges(ref, localRef, refPath string, lines []LineRange) []LineRange {
+	if len(lines) == 0 || r.git.bare || !isFile(filepath.Join(r.git.dir, r.path)) {
+		return lines
+	}
+	if localRef == "" {
+		r.warnf("could not compare %s with %s, line numbers are not translated", r.path, ref)
+		return lines
+	}
+	paths := []string{r.path}
+	if refPath != r.path {
+		// Both sides are needed for the rename detection
+		paths = append(paths, refPath)
+	}
+	diff, err := r.git.getDiff(localRef, paths...)
+	if err != nil {
+		// The go backend does not translate the lines, as documented
+		if !errors.Is(err, errNoObjects) {
+			r.warnf("could not compare %s with %s, line numbers are not translated", r.path, ref)
+		}
+		return lines
+	}
+	hunks, err := parseDiffHunks(diff)
+	if err != nil || len(hunks) == 0 {
+		return lines
+	}
+
+	return translateLines(hunks, lines, func(start, last int) {
+		r.warnf("lines %d-%d of %s include lines which do not exist at %s", start, last, r.path, ref)
+	})
+}
+
+// mapLinesToWorkTree maps the lines of the file at ref to the lines of the working file,
+// the inverse of mapLineRanges
+func (r gitRemote) mapLinesToWorkTree(ref string, lines []LineRange) []LineRange {
+	if len(lines) == 0 || ref == "" {
+		return lines
+	}
+	localRef := r.localRef(ref)
+	if localRef == "" {
+		r.warnf("%s is not in the local repository, line numbers are not translated, try git fetch", ref)
+		return lines
+	}
+	diff, err := r.git.getDiff(localRef, r.path)
+	if err != nil {
+		if !errors.Is(err, errNoObjects) {
+			r.warnf("could not compare %s with %s, line numbers are not translated", r.path, ref)
+		}
+		return lines
+	}
+	hunks, err := parseDiffHunks(diff)
+	if err != nil || len(hunks) == 0 {
+		return lines
+	}
+	for i, h := range hunks {
+		hunks[i] = diffHunk{oldStart: h.newStart, oldLines: h.newLines, newStart: h.oldStart, newLines: h.oldLines}
+	}
+	return translateLines(hunks, lines, func(start, last int) {
+		r.warnf("lines %d-%d at %s include lines which do not exist in the working file %s", start, last, ref, r.path)
+	})
+}
+
+// translateLines maps the line ranges of the new file to the old file by the hunks,
+// missing is called for the ranges including lines which do not exist in the old file
+func translateLines(hunks []diffHunk, lines []LineRange, missing func(start, last int)) []LineRange {
+	mapped := make([]LineRange, 0, len(lines))
+	for _, l := range lines {
+		last := max(l.Start, l.End)
+		for n := l.Start; n <= last; n++ {
+			if _, ok := mapLine(hunks, n); !ok {
+				missing(l.Start, last)
+				break
+			}
+		}
+		l.Start, _ = mapLine(hunks, l.Start)
+		if l.End != 0 {
+			l.End, _ = mapLine(hunks, l.End)
+		}
+		mapped = append(mapped, l)
+	}
+	return mapped
+}
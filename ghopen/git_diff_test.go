@@ -0,0 +1,165 @@
+package ghopen
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMapLine(t *testing.T) {
+	// old: 1..10
+	// new: 2 lines inserted after line 2, line 5 changed to 2 lines, line 8 deleted
+	diff := `diff --git a/file.txt b/file.txt
+--- a/file.txt
++++ b/file.txt
+@@ -2,0 +3,2 @@ line2
++added1
++added2
+@@ -5 +7,2 @@ line4
+-line5
++changed5a
++changed5b
+@@ -8 +10,0 @@ line7
+-line8`
+	hunks, err := parseDiffHunks(diff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hunks) != 3 {
+		t.Fatalf("want 3 hunks, got %d\n", len(hunks))
+	}
+
+	cases := []struct {
+		input  int
+		want   int
+		wantOk bool
+	}{
+		{input: 1, want: 1, wantOk: true},
+		{input: 2, want: 2, wantOk: true},
+		{input: 3, want: 2, wantOk: false}, // added
+		{input: 4, want: 2, wantOk: false}, // added
+		{input: 5, want: 3, wantOk: true},
+		{input: 6, want: 4, wantOk: true},
+		{input: 7, want: 5, wantOk: false}, // changed
+		{input: 8, want: 5, wantOk: false}, // changed
+		{input: 9, want: 6, wantOk: true},
+		{input: 10, want: 7, wantOk: true},
+		{input: 11, want: 9, wantOk: true}, // after deleted line 8
+	}
+	for _, c := range cases {
+		got, ok := mapLine(hunks, c.input)
+		if got != c.want || ok != c.wantOk {
+			t.Errorf("line %d want %d (%v), got %d (%v)\n", c.input, c.want, c.wantOk, got, ok)
+		}
+	}
+}
+
+func TestRemoteUrlMapLines(t *testing.T) {
+	testDir := mkTempDir()
+	defer os.RemoveAll(testDir)
+
+	mkGitRepo(t, testDir, "https://github.com/inouet/gh-open.git")
+	head := runGit(t, testDir, "rev-parse", "HEAD")
+
+	// Insert 2 lines at the top of the working file
+	os.WriteFile(filepath.Join(testDir, "README.md"), []byte("new1\nnew2\nline1\nline2\nline3\n"), 0666)
+
+	gr, err := newGitRemote(context.Background(), nil, filepath.Join(testDir, "README.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "https://github.com/inouet/gh-open/tree/" + head + "/README.md#L1-L3"
+	got, _ := gr.remoteURL("", testLines(3, 5))
+	if got != want {
+		t.Errorf("want '%s', got '%s'\n", want, got)
+	}
+}
+
+func TestRemoteUrlRenamed(t *testing.T) {
+	testDir := mkTempDir()
+	defer os.RemoveAll(testDir)
+
+	mkGitRepo(t, testDir, "https://github.com/inouet/gh-open.git")
+	head := runGit(t, testDir, "rev-parse", "HEAD")
+
+	// Rename README.md to GUIDE.md and insert a line at the top
+	runGit(t, testDir, "mv", "README.md", "GUIDE.md")
+	os.WriteFile(filepath.Join(testDir, "GUIDE.md"), []byte("new1\nline1\nline2\nline3\n"), 0666)
+	os.WriteFile(filepath.Join(testDir, "NEW.md"), []byte("new\n"), 0666)
+	runGit(t, testDir, "add", "-A")
+
+	gr, err := newGitRemote(context.Background(), nil, filepath.Join(testDir, "GUIDE.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "https://github.com/inouet/gh-open/tree/" + head + "/README.md#L2"
+	got, err := gr.remoteURL(head, testLines(3, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("want '%s', got '%s'\n", want, got)
+	}
+
+	gr, err = newGitRemote(context.Background(), nil, filepath.Join(testDir, "NEW.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = gr.remoteURL(head, nil)
+	if err == nil {
+		t.Errorf("new file want error, got nil\n")
+	}
+
+	// The names quoted by git diff without -z
+	runGit(t, testDir, "mv", "GUIDE.md", "gui\tdé \"1\".md")
+	gr, err = newGitRemote(context.Background(), nil, filepath.Join(testDir, "gui\tdé \"1\".md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = gr.remoteURL(head, testLines(3, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("want '%s', got '%s'\n", want, got)
+	}
+}
+
+func TestPathAtRefNotCommitted(t *testing.T) {
+	testDir := mkTempDir()
+	defer os.RemoveAll(testDir)
+
+	mkGitRepo(t, testDir, "https://github.com/inouet/gh-open.git")
+	os.WriteFile(filepath.Join(testDir, ".gitignore"), []byte("*.log\n"), 0666)
+	runGit(t, testDir, "add", ".gitignore")
+	runGit(t, testDir, "commit", "-q", "-m", "ignore logs")
+	head := runGit(t, testDir, "rev-parse", "HEAD")
+	os.WriteFile(filepath.Join(testDir, "debug.log"), []byte("log\n"), 0666)
+	os.WriteFile(filepath.Join(testDir, "draft.md"), []byte("draft\n"), 0666)
+
+	cases := map[string]struct {
+		path string
+		want string
+	}{
+		"ignored":   {path: "debug.log", want: "debug.log is ignored by .gitignore"},
+		"untracked": {path: "draft.md", want: "draft.md is untracked"},
+	}
+	for name, c := range cases {
+		gr, err := newGitRemote(context.Background(), nil, filepath.Join(testDir, c.path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = gr.remoteURL(head, nil)
+		if !errors.Is(err, ErrNotExist) || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s want '%s', got '%v'\n", name, c.want, err)
+		}
+		// A branch which is not fetched can not be compared with, but the file is checked
+		_, err = gr.pathAtRef("feature/not-fetched", "")
+		if !errors.Is(err, ErrNotExist) || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s (not fetched) want '%s', got '%v'\n", name, c.want, err)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package ghopen
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Values of gh-open.linkmode
+const (
+	linkModeCommit        string = "commit"
+	linkModeCurrentBranch string = "current-branch"
+	linkModeDefaultBranch string = "default-branch"
+)
+
+var commitHashRegexp = regexp.MustCompile(`^[0-9a-f]{40}([0-9a-f]{24})?$`)
+
+// Values of Options.Tag (--tag)
+const (
+	TagExact   string = "exact"   // The tag pointing at the commit
+	TagNearest string = "nearest" // The nearest annotated tag containing the commit
+)
+
+// refKind is the kind of a ref, some services have different URLs for branches, tags and commits
+type refKind int
+
+const (
+	refKindBranch refKind = iota
+	refKindTag
+	refKindCommit
+)
+
+func (k refKind) String() string {
+	switch k {
+	case refKindTag:
+		return "tag"
+	case refKindCommit:
+		return "commit"
+	}
+	return "branch"
+}
+
+// gitRef is the ref of the link
+type gitRef struct {
+	name string
+	kind refKind
+}
+
+// resolveRef returns the ref of the link.
+// The priority is -b, --ref, --default-branch, --current-branch, the commit pinned by the superproject, then HEAD.
+func (r gitRemote) resolveRef(branch string) (gitRef, error) {
+	var (
+		ref gitRef
+		err error
+	)
+	switch {
+	case branch != "":
+		ref = gitRef{branch, r.refKindOf(branch)}
+		err = r.checkBranchPushed(ref)
+	case r.ref != "":
+		ref, err = r.resolveRevision(r.ref)
+		if err == nil {
+			err = r.checkBranchPushed(ref)
+		}
+	case r.defaultBranch:
+		ref.name, err = r.defaultBranchName()
+	case r.currentBranch:
+		ref, err = r.currentBranchRef()
+	case r.commit != "":
+		ref = gitRef{r.commit, refKindCommit}
+	default:
+		ref.name, err = r.pushedCommit()
+		ref.kind = refKindCommit
+	}
+	if err != nil {
+		return gitRef{}, err
+	}
+
+	if r.tag != "" {
+		return r.tagRef(ref)
+	}
+
+	if r.permalink && ref.kind != refKindCommit {
+		commit, err := r.refCommit(ref)
+		if err != nil {
+			return gitRef{}, err
+		}
+		return gitRef{commit, refKindCommit}, nil
+	}
+	return ref, nil
+}
+
+// refKindOf returns the kind of a ref given by -b
+func (r gitRemote) refKindOf(name string) refKind {
+	switch {
+	case commitHashRegexp.MatchString(name):
+		return refKindCommit
+	case r.git.hasRef("refs/heads/" + name), r.git.hasRef("refs/remotes/" + remoteName + "/" + name):
+		return refKindBranch
+	case r.git.hasRef("refs/tags/" + name):
+		return refKindTag
+	}
+	return refKindBranch
+}
+
+// refCommit returns the full commit hash of the ref
+func (r gitRemote) refCommit(ref gitRef) (string, error) {
+	if ref.kind == refKindCommit {
+		return ref.name, nil
+	}
+	localRef := r.localRef(ref.name)
+	if localRef == "" {
+		return "", errorf(ErrUnknownRevision, "could not resolve '%s' to a commit, try git fetch", ref.name)
+	}
+	return r.git.verifyCommit(localRef)
+}
+
+// tagRef returns the tag of the commit of the ref.
+// --tag=exact requires a tag pointing at the commit,
+// --tag=nearest uses the nearest (first tagged) annotated tag containing the commit.
+func (r gitRemote) tagRef(ref gitRef) (gitRef, error) {
+	commit, err := r.refCommit(ref)
+	if err != nil {
+		return gitRef{}, err
+	}
+	var tag string
+	switch r.tag {
+	case TagNearest:
+		tag, err = r.git.nearestAnnotatedTag(commit)
+	default:
+		tag, err = r.git.tagPointingAt(commit)
+	}
+	if err != nil {
+		return gitRef{}, err
+	}
+	if tag == "" {
+		if r.tag == TagNearest {
+			return gitRef{}, errorf(ErrUnknownRevision, "no annotated tag contains %.7s", commit)
+		}
+		return gitRef{}, errorf(ErrUnknownRevision, "no tag points at %.7s, try --tag=nearest", commit)
+	}
+	return gitRef{tag, refKindTag}, nil
+}
+
+// resolveRevision keeps branch and tag names to make readable links,
+// and resolves other revisions (eg: HEAD~2, abc1234) to the full commit hash.
+func (r gitRemote) resolveRevision(rev string) (gitRef, error) {
+	switch {
+	case r.git.hasRef("refs/tags/" + rev):
+		return gitRef{rev, refKindTag}, nil
+	case r.git.hasRef("refs/heads/" + rev):
+		return gitRef{rev, refKindBranch}, nil
+	case strings.HasPrefix(rev, remoteName+"/") && r.git.hasRef("refs/remotes/"+rev):
+		return gitRef{strings.TrimPrefix(rev, remoteName+"/"), refKindBranch}, nil
+	}
+	commit, err := r.git.verifyCommit(rev)
+	if err != nil {
+		return gitRef{}, errorf(ErrUnknownRevision, "unknown revision: %s", rev)
+	}
+	return gitRef{commit, refKindCommit}, nil
+}
+
+// defaultBranchName returns the default branch of the remote (eg: main)
+func (r gitRemote) defaultBranchName() (string, error) {
+	head, err := r.git.getRemoteHead(remoteName)
+	if err == nil {
+		return strings.TrimPrefix(head, remoteName+"/"), nil
+	}
+
+	// refs/remotes/origin/HEAD is not set (eg: git remote add), ask the remote
+	symref, err := r.git.getRemoteHeadSymref(remoteName)
+	if err == nil {
+		for _, line := range strings.Split(symref, "\n") {
+			fields := strings.Fields(line)
+			if len(fields) == 3 && fields[0] == "ref:" && fields[2] == "HEAD" {
+				return strings.TrimPrefix(fields[1], "refs/heads/"), nil
+			}
+		}
+	}
+	return "", errorf(ErrUnknownRevision, "could not determine the default branch of %s, try git remote set-head %s --auto", remoteName, remoteName)
+}
+
+// currentBranchRef returns the upstream branch of the current branch.
+// The upstream may have a different name from the local branch (eg: git push -u origin topic:feature/topic).
+func (r gitRemote) currentBranchRef() (gitRef, error) {
+	branch, err := r.git.getCurrentBranch()
+	if err != nil {
+		r.warnf("HEAD is detached, linking to the commit instead of a branch")
+		commit, err := r.pushedCommit()
+		return gitRef{commit, refKindCommit}, err
+	}
+	remote := r.git.getConfig("branch."+branch+".remote", "")
+	merge := r.git.getConfig("branch."+branch+".merge", "")
+	if remote != remoteName || !strings.HasPrefix(merge, "refs/heads/") {
+		r.warnf("%s has no upstream branch on %s, the link may not exist on the remote", branch, remoteName)
+		return gitRef{branch, refKindBranch}, nil
+	}
+	ref := gitRef{strings.TrimPrefix(merge, "refs/heads/"), refKindBranch}
+	return ref, r.checkBranchPushed(ref)
+}
+
+// checkBranchPushed checks that the branch of the link exists on the remote by the remote-tracking branch,
+// or by git ls-remote when it is not fetched.
+// Without any remote-tracking branch (eg: never fetched), the remote is not asked.
+func (r gitRemote) checkBranchPushed(ref gitRef) error {
+	if ref.kind != refKindBranch || r.git.bare ||
+		r.git.hasRef("refs/remotes/"+remoteName+"/"+ref.name) || !r.git.hasRemoteRef(remoteName, "") {
+		return nil
+	}
+	out, err := r.git.getRemoteBranch(remoteName, ref.name)
+	if err != nil {
+		r.warnf("could not check that %s exists on %s, the link may not exist: %v", ref.name, remoteName, err)
+		return nil
+	}
+	if out == "" {
+		return errorf(ErrNotPushed, "branch %s is not pushed to %s, try git push -u %s %s", ref.name, remoteName, remoteName, ref.name)
+	}
+	r.warnf("%s is not fetched from %s, try git fetch", ref.name, remoteName)
+	return nil
+}
@@ -0,0 +1,254 @@
+package ghopen
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveRef(t *testing.T) {
+	testDir := mkTempDir()
+	defer os.RemoveAll(testDir)
+
+	mkGitRepo(t, testDir, "https://github.com/inouet/gh-open.git")
+	first := runGit(t, testDir, "rev-parse", "HEAD")
+	runGit(t, testDir, "tag", "v1.0")
+	runGit(t, testDir, "commit", "-q", "--allow-empty", "-m", "second")
+	runGit(t, testDir, "commit", "-q", "--allow-empty", "-m", "third")
+	runGit(t, testDir, "tag", "rc")
+	runGit(t, testDir, "commit", "-q", "--allow-empty", "-m", "fourth")
+	runGit(t, testDir, "tag", "-a", "-m", "v2.0", "v2.0")
+	runGit(t, testDir, "reset", "-q", "--hard", "HEAD~2")
+	head := runGit(t, testDir, "rev-parse", "HEAD")
+	runGit(t, testDir, "update-ref", "refs/remotes/origin/main", head)
+	runGit(t, testDir, "update-ref", "refs/remotes/origin/master", head)
+	runGit(t, testDir, "symbolic-ref", "refs/remotes/origin/HEAD", "refs/remotes/origin/main")
+
+	cases := map[string]struct {
+		path          string
+		branch        string
+		ref           string
+		permalink     bool
+		defaultBranch bool
+		tag           string
+		want          string
+	}{
+		"ref-tag": {
+			path: "README.md",
+			ref:  "v1.0",
+			want: "https://github.com/inouet/gh-open/tree/v1.0/README.md",
+		},
+		"ref-expression": {
+			path: "README.md",
+			ref:  "HEAD~1",
+			want: "https://github.com/inouet/gh-open/tree/" + first + "/README.md",
+		},
+		"ref-remote-branch": {
+			path: "README.md",
+			ref:  "origin/main",
+			want: "https://github.com/inouet/gh-open/tree/main/README.md",
+		},
+		"permalink-branch": {
+			path:      "README.md",
+			branch:    "master",
+			permalink: true,
+			want:      "https://github.com/inouet/gh-open/tree/" + head + "/README.md",
+		},
+		"permalink-root": {
+			path:      "",
+			permalink: true,
+			want:      "https://github.com/inouet/gh-open/tree/" + head + "/",
+		},
+		"default-branch": {
+			path:          "README.md",
+			defaultBranch: true,
+			want:          "https://github.com/inouet/gh-open/tree/main/README.md",
+		},
+		"tag-exact": {
+			path:   "README.md",
+			branch: first,
+			tag:    TagExact,
+			want:   "https://github.com/inouet/gh-open/tree/v1.0/README.md",
+		},
+		"tag-nearest": {
+			path: "README.md",
+			tag:  TagNearest,
+			want: "https://github.com/inouet/gh-open/tree/v2.0/README.md",
+		},
+	}
+
+	for name, c := range cases {
+		gr, err := newGitRemote(context.Background(), nil, filepath.Join(testDir, c.path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		gr.ref = c.ref
+		gr.permalink = c.permalink
+		gr.defaultBranch = c.defaultBranch
+		gr.tag = c.tag
+		got, err := gr.remoteURL(c.branch, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if got != c.want {
+			t.Errorf("%s want '%s', got '%s'\n", name, c.want, got)
+		}
+	}
+
+	// No tag points at HEAD
+	gr, _ := newGitRemote(context.Background(), nil, testDir)
+	gr.tag = TagExact
+	_, err := gr.remoteURL("", nil)
+	if !errors.Is(err, ErrUnknownRevision) {
+		t.Errorf("tag-exact want ErrUnknownRevision, got %v\n", err)
+	}
+
+	// The other errors are not mapped to ErrUnknownRevision
+	runner := failRunner{command: "for-each-ref", err: context.DeadlineExceeded}
+	_, err = Resolver{Runner: runner}.Resolve(context.Background(), testDir, Options{Tag: TagNearest})
+	if !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrUnknownRevision) {
+		t.Errorf("timeout want context.DeadlineExceeded, got %v\n", err)
+	}
+	_, err = Resolver{Backend: BackendGo}.Resolve(context.Background(), testDir, Options{Tag: TagExact})
+	if !errors.Is(err, errNoObjects) || errors.Is(err, ErrUnknownRevision) {
+		t.Errorf("go backend want errNoObjects, got %v\n", err)
+	}
+
+	_, err = Resolve(context.Background(), testDir, Options{Tag: "latest"})
+	if !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("unknown tag want ErrInvalidOption, got %v\n", err)
+	}
+}
+
+// failRunner runs git, and fails the command by the error
+type failRunner struct {
+	command string
+	err     error
+}
+
+func (f failRunner) Run(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	if args[0] == f.command {
+		return nil, &GitError{Args: args, Err: f.err}
+	}
+	return commandRunner{}.Run(ctx, dir, args...)
+}
+
+func TestCurrentBranch(t *testing.T) {
+	testDir := mkTempDir()
+	defer os.RemoveAll(testDir)
+
+	mkGitRepo(t, testDir, "https://github.com/inouet/gh-open.git")
+	head := runGit(t, testDir, "rev-parse", "HEAD")
+	runGit(t, testDir, "update-ref", "refs/remotes/origin/feature/a#b", head)
+	runGit(t, testDir, "checkout", "-q", "-b", "topic")
+	runGit(t, testDir, "branch", "-q", "--set-upstream-to=origin/feature/a#b")
+
+	cases := []struct {
+		name          string
+		setup         func()
+		currentBranch bool
+		linkMode      string
+		want          string
+	}{
+		{
+			name:          "upstream-with-different-name",
+			currentBranch: true,
+			linkMode:      linkModeCommit,
+			want:          "https://github.com/inouet/gh-open/tree/feature/a%23b/README.md",
+		},
+		{
+			name:     "config-link-mode",
+			linkMode: linkModeCurrentBranch,
+			want:     "https://github.com/inouet/gh-open/tree/feature/a%23b/README.md",
+		},
+		{
+			name:          "no-upstream",
+			setup:         func() { runGit(t, testDir, "checkout", "-q", "-b", "local/only") },
+			currentBranch: true,
+			linkMode:      linkModeCommit,
+			want:          "https://github.com/inouet/gh-open/tree/local/only/README.md",
+		},
+		{
+			name:          "detached-head",
+			setup:         func() { runGit(t, testDir, "checkout", "-q", "--detach") },
+			currentBranch: true,
+			linkMode:      linkModeCommit,
+			want:          "https://github.com/inouet/gh-open/tree/" + head + "/README.md",
+		},
+	}
+	for _, c := range cases {
+		if c.setup != nil {
+			c.setup()
+		}
+		runGit(t, testDir, "config", gitConfigLinkModeName, c.linkMode)
+		// The config and HEAD are read when the gitRemote is created
+		gr, err := newGitRemote(context.Background(), nil, filepath.Join(testDir, "README.md"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		gr.currentBranch = c.currentBranch
+		got, err := gr.remoteURL("", nil)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if got != c.want {
+			t.Errorf("%s want '%s', got '%s'\n", c.name, c.want, got)
+		}
+	}
+}
+
+func TestCheckBranchPushed(t *testing.T) {
+	testDir := mkTempDir()
+	defer os.RemoveAll(testDir)
+
+	// origin is a local bare repository in place of github.com
+	originDir := filepath.Join(testDir, "origin.git")
+	workDir := filepath.Join(testDir, "work")
+	mkGitRepo(t, workDir, "https://github.com/inouet/gh-open.git")
+	runGit(t, testDir, "init", "-q", "--bare", originDir)
+	runGit(t, workDir, "config", "url."+originDir+".insteadOf", "https://github.com/inouet/gh-open.git")
+	runGit(t, workDir, "push", "-q", "origin", "master")
+	runGit(t, workDir, "branch", "topic")
+	runGit(t, workDir, "branch", "unfetched")
+	runGit(t, workDir, "push", "-q", "origin", "unfetched")
+	runGit(t, workDir, "update-ref", "-d", "refs/remotes/origin/unfetched")
+
+	cases := map[string]struct {
+		branch      string
+		wantErr     error
+		wantWarning string
+	}{
+		"pushed":    {branch: "master"},
+		"unfetched": {branch: "unfetched", wantWarning: "unfetched is not fetched from origin"},
+		"not-pushed": {
+			branch:  "topic",
+			wantErr: ErrNotPushed,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			var warnings []string
+			resolver := Resolver{Warn: func(msg string) { warnings = append(warnings, msg) }}
+			loc, err := resolver.Resolve(context.Background(), filepath.Join(workDir, "README.md"), Options{Branch: c.branch})
+			if c.wantErr != nil {
+				if !errors.Is(err, c.wantErr) {
+					t.Errorf("want %v, got %v\n", c.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := "https://github.com/inouet/gh-open/tree/" + c.branch + "/README.md"
+			if loc.URL != want {
+				t.Errorf("want '%s', got '%s'\n", want, loc.URL)
+			}
+			if c.wantWarning != "" && (len(warnings) != 1 || !strings.Contains(warnings[0], c.wantWarning)) {
+				t.Errorf("want the warning '%s', got %v\n", c.wantWarning, warnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,519 @@
+package ghopen
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	neturl "net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	vcsurl "github.com/gitsight/go-vcsurl"
+)
+
+const (
+	gitConfigURLTypeName       string = "gh-open.urltype"
+	gitConfigProtocolName      string = "gh-open.protocol"
+	gitConfigRequirePushedName string = "gh-open.requirepushed"
+	gitConfigLinkModeName      string = "gh-open.linkmode"
+
+	remoteName string = "origin"
+)
+
+// gitRemote is a struct
+type gitRemote struct {
+	git     *git
+	path    string // Relative path from git top directory
+	remote  string // Remote URL used instead of remote.origin.url (submodules)
+	commit  string // Commit pinned by the superproject (submodules)
+	gitlink bool   // The path is the gitlink of a submodule in the superproject, which has no lines
+
+	ref           string // Any revision to link to instead of HEAD (eg: v1.0, HEAD~2)
+	permalink     bool   // Always link to the full commit hash
+	defaultBranch bool   // Link to the default branch of the remote
+	currentBranch bool   // Link to the upstream branch of the current branch
+	tag           string // Link to the tag of the commit, "exact" or "nearest"
+	match         string // Select the lines of the first match of the regular expression
+	symbol        string // Select the lines of the declaration of a Go function, method or type
+	requirePushed bool   // Fail when HEAD is not pushed instead of falling back to the merge-base
+
+	warn func(msg string) // Receives the warnings, which are dropped when nil
+}
+
+func (r gitRemote) warnf(format string, a ...interface{}) {
+	if r.warn != nil {
+		r.warn(fmt.Sprintf(format, a...))
+	}
+}
+
+// Enhanced regex to match both standard git SSH URLs and organization format URLs
+var orgSSHRegex = regexp.MustCompile(`^(git|org-[a-zA-Z0-9_-]+)@([a-zA-Z0-9._-]+):([a-zA-Z0-9/_-]+)(/[a-zA-Z0-9/_-]+)*(.git)?$`)
+
+// scp-like syntax of git: [user@]host:path
+var scpLikeRegexp = regexp.MustCompile(`^([^@/:]+@[^@/:]+):([^/].*)$`)
+
+// parseSSHRemoteURL parses SSH remote URLs including both standard format and organization format
+// e.g. git@github.com:user/repo.git or org-1234@github.com:user/repo.git
+func parseSSHRemoteURL(remoteURL string) (host, username, repo string, err error) {
+	matches := orgSSHRegex.FindStringSubmatch(remoteURL)
+	if matches == nil {
+		return "", "", "", fmt.Errorf("invalid SSH remote URL format: %s", remoteURL)
+	}
+
+	host = matches[2]
+	repoPath := matches[3]
+
+	// Remove .git suffix if present
+	repoPath = strings.TrimSuffix(repoPath, ".git")
+
+	// Split the path into username and repo name
+	parts := strings.Split(repoPath, "/")
+	if len(parts) < 2 {
+		return "", "", "", fmt.Errorf("invalid repository path: %s", repoPath)
+	}
+
+	username = parts[0]
+	repo = parts[len(parts)-1]
+
+	return host, username, repo, nil
+}
+
+func newGitRemote(ctx context.Context, b backend, objectPath string) (*gitRemote, error) {
+	isFile := isFile(objectPath)
+	isDir := isDir(objectPath)
+
+	absPath, _ := filepath.Abs(objectPath)
+	absDir := absPath
+	if !isDir {
+		// A path which does not exist may still be a path inside the tree of a bare repository
+		absDir = existingDir(filepath.Dir(absPath))
+	}
+
+	g, err := newGit(ctx, b, absDir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if g.bare {
+		treePath, err := bareTreePath(g.dir, absPath, objectPath)
+		if err != nil {
+			return nil, err
+		}
+		return &gitRemote{git: g, path: treePath}, nil
+	}
+
+	if !isFile && !isDir {
+		return nil, errorf(ErrNotExist, "%s: no such file or directory", objectPath)
+	}
+
+	if !g.isInsideWorkTree() {
+		return nil, ErrNotRepository
+	}
+
+	relPath, err := relativePath(g.dir, absPath)
+	if err != nil {
+		return nil, err
+	}
+	gr := gitRemote{git: g, path: relPath}
+
+	err = gr.resolveSubmodule()
+	if err != nil {
+		return nil, err
+	}
+
+	return &gr, nil
+}
+
+// bareTreePath returns the path inside the tree for a bare repository.
+// A bare repository has no files, so the object path is either inside
+// the git directory (eg: repo.git/path/to/file) or already a tree path
+// (eg: GIT_DIR=repo.git gh-open path/to/file).
+func bareTreePath(gitDir, absPath, objectPath string) (string, error) {
+	relPath, err := relativePath(gitDir, absPath)
+	if err == nil && relPath != ".." && !strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return filepath.ToSlash(relPath), nil
+	}
+	if filepath.IsAbs(objectPath) {
+		return "", errorf(ErrNotExist, "%s: outside of the bare repository %s", objectPath, gitDir)
+	}
+	treePath := filepath.ToSlash(filepath.Clean(objectPath))
+	if treePath == "." {
+		treePath = ""
+	}
+	return treePath, nil
+}
+
+func (r gitRemote) remoteURL(branch string, lines []LineRange) (string, error) {
+	loc, err := r.resolve(branch, lines)
+	if err != nil {
+		return "", err
+	}
+	return loc.URL, nil
+}
+
+func (r gitRemote) resolve(branch string, lines []LineRange) (Location, error) {
+	if r.gitlink && len(lines) > 0 {
+		r.warnf("%s is a submodule without a linkable url, the lines are not linked", r.path)
+		lines = nil
+	}
+	remote := r.remote
+	if remote == "" {
+		var err error
+		remote, err = r.git.getRemoteOriginURL()
+		if err != nil {
+			return Location{}, errorf(ErrNoRemote, "no remote %s (remote.%s.url is not set)", remoteName, remoteName)
+		}
+	}
+
+	// If it cannot be determined from the remote domain,
+	//   read the setting from git config and make a judgment based on it.
+	urlType := r.git.getConfig(gitConfigURLTypeName, "")
+	scheme := r.git.getConfig(gitConfigProtocolName, "https")
+
+	newURL, err := parseRemoteURL(remote, scheme)
+	if err != nil {
+		return Location{}, err
+	}
+	owner, repo := splitRepoPath(newURL.Path)
+	loc := Location{Host: newURL.Host, Owner: owner, Repo: repo, Path: filepath.ToSlash(r.path)}
+
+	if branch == "" && r.ref == "" && !r.defaultBranch && !r.currentBranch && r.commit == "" {
+		// Default link mode from git config
+		switch mode := r.git.getConfig(gitConfigLinkModeName, linkModeCommit); mode {
+		case linkModeCommit:
+		case linkModeCurrentBranch:
+			r.currentBranch = true
+		case linkModeDefaultBranch:
+			r.defaultBranch = true
+		default:
+			return Location{}, fmt.Errorf("invalid %s: '%s' (valid: %s, %s, %s)", gitConfigLinkModeName, mode, linkModeCommit, linkModeCurrentBranch, linkModeDefaultBranch)
+		}
+	}
+
+	if (r.match != "" || r.symbol != "") && (r.path == "" || !r.git.bare && isDir(filepath.Join(r.git.dir, r.path))) {
+		return Location{}, errorf(ErrInvalidOption, "--match and --symbol select the lines of a file, not of a directory")
+	}
+
+	if r.path == "" && branch == "" && r.commit == "" && r.ref == "" && r.tag == "" && !r.permalink && !r.defaultBranch && !r.currentBranch {
+		loc.URL = newURL.String()
+		return loc, nil
+	}
+
+	err = r.checkLineRanges(lines)
+	if err != nil {
+		return Location{}, err
+	}
+
+	ref, err := r.resolveRef(branch)
+	if err != nil {
+		return Location{}, err
+	}
+
+	// The ref in the local repository, read by the checks and the translations below
+	localRef := r.localRef(ref.name)
+	refPath, err := r.pathAtRef(ref.name, localRef)
+	if err != nil {
+		return Location{}, err
+	}
+	err = r.checkLineRangesAt(localRef, refPath, lines)
+	if err != nil {
+		return Location{}, err
+	}
+	if r.match != "" || r.symbol != "" {
+		// Found at ref, no need to translate
+		lines, err = r.locateLines(ref.name, localRef, refPath)
+		if err != nil {
+			return Location{}, err
+		}
+	} else {
+		lines = r.mapLineRanges(ref.name, localRef, refPath, lines)
+	}
+
+	remoteURL, err := buildURL(newURL, refPath, ref, lines, urlType)
+	if err != nil {
+		return Location{}, err
+	}
+	if hasColumns(lines) && !supportsColumns(newURL, urlType) {
+		r.warnf("%s does not support columns, linking to the lines", serviceHost(newURL, urlType))
+	}
+
+	loc.Ref = ref.name
+	loc.RefKind = ref.kind.String()
+	loc.Path = filepath.ToSlash(refPath)
+	loc.Lines = lines
+	loc.URL = remoteURL
+	return loc, nil
+}
+
+// hasColumns reports whether any of the line ranges selects columns
+func hasColumns(lines []LineRange) bool {
+	for _, l := range lines {
+		if l.StartCol != 0 || l.EndCol != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// splitRepoPath splits the path of the repository URL into the owner and the repository name
+//
+//	/inouet/gh-open => inouet, gh-open
+//	/group/subgroup/repo => group/subgroup, repo
+//	/org/project/_git/repo => org/project, repo (Azure DevOps)
+func splitRepoPath(repoPath string) (string, string) {
+	parts := strings.Split(strings.Trim(repoPath, "/"), "/")
+	repo := parts[len(parts)-1]
+	owner := strings.TrimSuffix(strings.Join(parts[:len(parts)-1], "/"), "/_git")
+	return owner, repo
+}
+
+// pushedCommit returns the HEAD commit if it exists on the remote.
+// Otherwise it falls back to the merge-base with the upstream,
+// so that the link does not point to a local only commit.
+func (r gitRemote) pushedCommit() (string, error) {
+	commit, err := r.git.getCommitHash()
+	if err != nil {
+		return "", err
+	}
+
+	// Without remote-tracking refs (eg: never fetched) there is nothing to check against
+	if !r.git.hasRemoteRef(remoteName, "") || r.git.hasRemoteRef(remoteName, commit) {
+		return commit, nil
+	}
+
+	if r.requirePushed || r.git.getConfigBool(gitConfigRequirePushedName, false) {
+		return "", errorf(ErrNotPushed, "HEAD (%.7s) is not pushed to %s, push it first (eg: git push %s HEAD)", commit, remoteName, remoteName)
+	}
+
+	upstream, err := r.git.getUpstream()
+	if err != nil || !strings.HasPrefix(upstream, remoteName+"/") {
+		upstream = remoteName + "/HEAD"
+	}
+	base, err := r.git.getMergeBase(commit, upstream)
+	if err != nil {
+		r.warnf("HEAD (%.7s) is not pushed to %s, the link may not exist on the remote", commit, remoteName)
+		return commit, nil
+	}
+	r.warnf("HEAD (%.7s) is not pushed to %s, linking to the merge-base with %s (%.7s)", commit, remoteName, upstream, base)
+	return base, nil
+}
+
+// parseRemoteURL converts a git remote URL into the web URL of the repository
+//
+//	e.g. git@github.com:inouet/gh-open.git => https://github.com/inouet/gh-open
+func parseRemoteURL(remote, scheme string) (neturl.URL, error) {
+	// Try to handle special SSH URL format with organization ID (org-ID@github.com:user/repo.git)
+	if strings.Contains(remote, "@") && strings.Contains(remote, ":") && !strings.HasPrefix(remote, "git@") {
+		host, username, repo, err := parseSSHRemoteURL(remote)
+		if err == nil {
+			return neturl.URL{
+				Scheme: scheme,
+				Host:   host,
+				Path:   "/" + username + "/" + repo,
+			}, nil
+		}
+		// If parsing fails, fall back to the standard method
+	}
+
+	// Parse with gitsight/go-vcsurl for standard formats
+	info, err := vcsurl.Parse(remote)
+	if m := scpLikeRegexp.FindStringSubmatch(remote); err != nil && m != nil {
+		// scp-like syntax with any user (eg: org@vs-ssh.visualstudio.com:v3/org/project/repo)
+		info, err = vcsurl.Parse("ssh://" + m[1] + "/" + m[2])
+	}
+	if err != nil {
+		return neturl.URL{}, err
+	}
+	if info.Host == "" {
+		// eg: local path
+		return neturl.URL{}, errorf(ErrUnsupportedHost, "remote url has no host: %s", remote)
+	}
+
+	host, fullName := string(info.Host), info.FullName
+	if strings.HasPrefix(fullName, "v3/") {
+		host, fullName = azureSSHToWeb(host, fullName)
+	}
+
+	return neturl.URL{
+		Scheme: scheme,
+		Host:   host,
+		Path:   "/" + fullName,
+	}, nil
+}
+
+// azureSSHToWeb converts the host and path of Azure DevOps SSH urls to the web ones
+//
+//	ssh.dev.azure.com:v3/<org>/<project>/<repo> => dev.azure.com/<org>/<project>/_git/<repo>
+//	vs-ssh.visualstudio.com:v3/<org>/<project>/<repo> => <org>.visualstudio.com/<project>/_git/<repo>
+func azureSSHToWeb(host, fullName string) (string, string) {
+	parts := strings.Split(strings.TrimPrefix(fullName, "v3/"), "/")
+	if len(parts) != 3 {
+		return host, fullName
+	}
+	org, project, repo := parts[0], parts[1], parts[2]
+	switch host {
+	case "ssh.dev.azure.com":
+		return "dev.azure.com", org + "/" + project + "/_git/" + repo
+	case "vs-ssh.visualstudio.com":
+		return org + ".visualstudio.com", project + "/_git/" + repo
+	}
+	return host, fullName
+}
+
+func isFile(name string) bool {
+	fi, err := os.Stat(name)
+	if err != nil {
+		return false
+	}
+	if fi.Mode().IsRegular() {
+		return true
+	}
+	return false
+}
+
+func isDir(name string) bool {
+	fi, err := os.Stat(name)
+	if err != nil {
+		return false
+	}
+	if fi.Mode().IsDir() {
+		return true
+	}
+	return false
+}
+
+// existingDir returns the nearest directory which exists
+func existingDir(dir string) string {
+	for !isDir(dir) && filepath.Dir(dir) != dir {
+		dir = filepath.Dir(dir)
+	}
+	return dir
+}
+
+func relativePath(obj1, obj2 string) (string, error) {
+	o1 := evalSymlinks(obj1)
+	o2 := evalSymlinks(obj2)
+	relPath, err := filepath.Rel(o1, o2)
+	if err != nil {
+		return "", err
+	}
+
+	if relPath == "." {
+		relPath = ""
+	}
+	return relPath, nil
+}
+
+// evalSymlinks resolves symlinks of the existing part of the path,
+// so that paths which do not exist (yet) are comparable with resolved ones.
+func evalSymlinks(path string) string {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err == nil {
+		return resolved
+	}
+	dir, file := filepath.Split(filepath.Clean(path))
+	if dir == "" || filepath.Clean(dir) == path {
+		return path
+	}
+	return filepath.Join(evalSymlinks(filepath.Clean(dir)), file)
+}
+
+// LineRange is a range of lines to select, 0 means not given.
+//
+//	10:5-12:20 => LineRange{Start: 10, StartCol: 5, End: 12, EndCol: 20}
+type LineRange struct {
+	Start    int `json:"start"`
+	StartCol int `json:"start_column,omitempty"`
+	End      int `json:"end,omitempty"`
+	EndCol   int `json:"end_column,omitempty"`
+}
+
+// String returns the range in the format of ParseLines (eg: 10:5-12:20)
+func (l LineRange) String() string {
+	s := strconv.Itoa(l.Start)
+	if l.StartCol != 0 {
+		s += ":" + strconv.Itoa(l.StartCol)
+	}
+	if l.End != 0 {
+		s += "-" + strconv.Itoa(l.End)
+		if l.EndCol != 0 {
+			s += ":" + strconv.Itoa(l.EndCol)
+		}
+	}
+	return s
+}
+
+var lineRangeRegexp = regexp.MustCompile(`^([0-9]+)(?::([0-9]+))?(?:-([0-9]+)(?::([0-9]+))?)?$`)
+
+// ParseLines parses the line ranges of the -l option.
+// valid format: 20, 20-30, 20:5-30:10 or comma separated ranges (eg: 10-20,30)
+func ParseLines(line string) ([]LineRange, error) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return nil, nil
+	}
+	var lines []LineRange
+	for _, rangeStr := range strings.Split(line, ",") {
+		m := lineRangeRegexp.FindStringSubmatch(strings.TrimSpace(rangeStr))
+		if m == nil {
+			return nil, errorf(ErrInvalidLine, "invalid line format")
+		}
+		var l LineRange
+		l.Start, _ = strconv.Atoi(m[1])
+		l.StartCol, _ = strconv.Atoi(m[2])
+		l.End, _ = strconv.Atoi(m[3])
+		l.EndCol, _ = strconv.Atoi(m[4])
+		if l.Start == 0 || (m[3] != "" && l.End < l.Start) {
+			return nil, errorf(ErrInvalidLine, "invalid line range: %s", rangeStr)
+		}
+		if l.End == l.Start && l.EndCol != 0 && l.EndCol < l.StartCol {
+			return nil, errorf(ErrInvalidLine, "invalid column range: %s", rangeStr)
+		}
+		lines = append(lines, l)
+	}
+	return lines, nil
+}
+
+// checkLineRanges checks that the lines exist in the working file
+func (r gitRemote) checkLineRanges(lines []LineRange) error {
+	if len(lines) == 0 || r.git.bare {
+		return nil
+	}
+	content, err := os.ReadFile(filepath.Join(r.git.dir, r.path))
+	if err != nil {
+		// eg: directory
+		return nil
+	}
+	return checkLineCount(lines, r.path, content)
+}
+
+// checkLineRangesAt checks the lines against the file at localRef, for a bare repository which has no working files
+func (r gitRemote) checkLineRangesAt(localRef, refPath string, lines []LineRange) error {
+	if len(lines) == 0 || !r.git.bare || localRef == "" {
+		return nil
+	}
+	content, err := r.git.getFileContent(localRef, filepath.ToSlash(refPath))
+	if err != nil {
+		// eg: directory, or the go backend
+		return nil
+	}
+	return checkLineCount(lines, refPath, content)
+}
+
+// checkLineCount checks that the lines are in the content of the file
+func checkLineCount(lines []LineRange, path string, content []byte) error {
+	count := bytes.Count(content, []byte("\n"))
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		count++
+	}
+	for _, l := range lines {
+		if l.Start > count || l.End > count {
+			return errorf(ErrInvalidLine, "line %d is out of range: %s has %d lines", max(l.Start, l.End), path, count)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,732 @@
+package ghopen
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	vcsurl "github.com/gitsight/go-vcsurl"
+)
+
+func mkTempDir() string {
+	tmpDir := os.TempDir()
+	testDir, err := os.MkdirTemp(tmpDir, "tests-*")
+	if err != nil {
+		log.Fatal(err)
+	}
+	return testDir
+}
+
+// runGit runs git in dir for building fixture repositories
+func runGit(t testing.TB, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=gh-open", "GIT_AUTHOR_EMAIL=gh-open@example.com",
+		"GIT_COMMITTER_NAME=gh-open", "GIT_COMMITTER_EMAIL=gh-open@example.com",
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// mkGitRepo creates a repository with one commit containing README.md
+func mkGitRepo(t testing.TB, dir, origin string) {
+	t.Helper()
+	os.MkdirAll(dir, 0777)
+	runGit(t, dir, "init", "-q", "-b", "master")
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("line1\nline2\nline3\n"), 0666)
+	runGit(t, dir, "add", "README.md")
+	runGit(t, dir, "commit", "-q", "-m", "initial")
+	if origin != "" {
+		runGit(t, dir, "remote", "add", "origin", origin)
+	}
+}
+
+// testLines returns the line range line1-line2, nil when line1 is 0
+func testLines(line1, line2 int) []LineRange {
+	if line1 == 0 {
+		return nil
+	}
+	return []LineRange{{Start: line1, End: line2}}
+}
+
+// fakeRunner answers the git commands by the outputs keyed by the arguments joined by spaces,
+// and fails as git does for the others
+type fakeRunner struct {
+	outputs map[string]string
+	calls   []string
+}
+
+func (f *fakeRunner) Run(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	command := strings.Join(args, " ")
+	f.calls = append(f.calls, command)
+	out, ok := f.outputs[command]
+	if !ok {
+		return nil, &GitError{Args: args, Stderr: "fatal: unexpected command", Err: errors.New("exit status 128")}
+	}
+	return []byte(out), nil
+}
+
+// remoteCases are the remotes of every provider, linking to README.md of mkGitRepo
+var remoteCases = map[string]struct {
+	origin string
+	config map[string]string
+	branch string
+	lines  []LineRange
+	want   string
+}{
+	"github-https": {
+		origin: "https://github.com/githubtraining/github-cheat-sheet.git",
+		branch: "master",
+		lines:  testLines(2, 3),
+		want:   "https://github.com/githubtraining/github-cheat-sheet/tree/master/README.md#L2-L3",
+	},
+	"github-ssh": {
+		origin: "git@github.com:inouet/gh-open.git",
+		branch: "master",
+		lines:  testLines(2, 0),
+		want:   "https://github.com/inouet/gh-open/tree/master/README.md#L2",
+	},
+	"github-org-ssh": {
+		origin: "org-123@github.com:inouet/gh-open.git",
+		branch: "master",
+		want:   "https://github.com/inouet/gh-open/tree/master/README.md",
+	},
+	"gitlab": {
+		origin: "https://gitlab.com/gitlab-examples/docker.git",
+		branch: "master",
+		lines:  testLines(1, 2),
+		want:   "https://gitlab.com/gitlab-examples/docker/-/blob/master/README.md#L1-2",
+	},
+	"bitbucket": {
+		origin: "git@bitbucket.org:atn13/bitbucketstationlocations.git",
+		branch: "master",
+		lines:  testLines(2, 3),
+		want:   "https://bitbucket.org/atn13/bitbucketstationlocations/src/master/README.md#lines-2:3",
+	},
+	"googlesource": {
+		origin: "https://gerrit.googlesource.com/gerrit",
+		branch: "master",
+		lines:  testLines(2, 0),
+		want:   "https://gerrit.googlesource.com/gerrit/+/master/README.md#2",
+	},
+	"codeberg": {
+		origin: "https://codeberg.org/forgejo/forgejo.git",
+		branch: "master",
+		lines:  testLines(2, 3),
+		want:   "https://codeberg.org/forgejo/forgejo/src/branch/master/README.md#L2-L3",
+	},
+	"gitea-ssh": {
+		origin: "git@gitea.com:gitea/tea.git",
+		branch: "master",
+		want:   "https://gitea.com/gitea/tea/src/branch/master/README.md",
+	},
+	"azure-https": {
+		origin: "https://org@dev.azure.com/org/project/_git/repo",
+		branch: "master",
+		lines:  testLines(2, 3),
+		want:   "https://dev.azure.com/org/project/_git/repo?line=2&lineEnd=4&lineEndColumn=1&lineStartColumn=1&lineStyle=plain&path=%2FREADME.md&version=GBmaster",
+	},
+	"azure-ssh": {
+		origin: "git@ssh.dev.azure.com:v3/org/project/repo",
+		branch: "master",
+		want:   "https://dev.azure.com/org/project/_git/repo?path=%2FREADME.md&version=GBmaster",
+	},
+	"visualstudio-ssh": {
+		origin: "org@vs-ssh.visualstudio.com:v3/org/project/repo",
+		branch: "master",
+		want:   "https://org.visualstudio.com/project/_git/repo?path=%2FREADME.md&version=GBmaster",
+	},
+	"urltype-and-protocol": {
+		origin: "https://github.com/githubtraining/github-cheat-sheet.git",
+		config: map[string]string{gitConfigURLTypeName: "bitbucket.org", gitConfigProtocolName: "http"},
+		branch: "master",
+		lines:  testLines(2, 3),
+		want:   "http://github.com/githubtraining/github-cheat-sheet/src/master/README.md#lines-2:3",
+	},
+}
+
+func TestRemoteUrl(t *testing.T) {
+	testDir := mkTempDir()
+	defer os.RemoveAll(testDir)
+
+	mkGitRepo(t, testDir, "git@github.com:inouet/gh-open.git")
+
+	cases := map[string]struct {
+		path   string
+		branch string
+		line1  int
+		line2  int
+		want   string
+	}{
+		"root-dir": {
+			path:   testDir,
+			branch: "",
+			line1:  0,
+			line2:  0,
+			want:   "https://github.com/inouet/gh-open",
+		},
+		"root-dir-master": {
+			path:   testDir,
+			branch: "master",
+			line1:  0,
+			line2:  0,
+			want:   "https://github.com/inouet/gh-open/tree/master/",
+		},
+		"readme.md": {
+			path:   filepath.Join(testDir, "README.md"),
+			branch: "master",
+			line1:  2,
+			line2:  0,
+			want:   "https://github.com/inouet/gh-open/tree/master/README.md#L2",
+		},
+	}
+
+	for name, c := range cases {
+		gr, err := newGitRemote(context.Background(), nil, c.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, _ := gr.remoteURL(c.branch, testLines(c.line1, c.line2))
+		if got != c.want {
+			t.Errorf("%s want '%s', got '%s'\n", name, c.want, got)
+		}
+	}
+}
+
+func TestRemoteUrlProviders(t *testing.T) {
+	testDir := mkTempDir()
+	defer os.RemoveAll(testDir)
+
+	for name, c := range remoteCases {
+		t.Run(name, func(t *testing.T) {
+			repoDir := filepath.Join(testDir, name)
+			mkGitRepo(t, repoDir, c.origin)
+			for key, value := range c.config {
+				runGit(t, repoDir, "config", key, value)
+			}
+
+			gr, err := newGitRemote(context.Background(), nil, filepath.Join(repoDir, "README.md"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := gr.remoteURL(c.branch, c.lines)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != c.want {
+				t.Errorf("want '%s', got '%s'\n", c.want, got)
+			}
+		})
+	}
+}
+
+func TestConfig(t *testing.T) {
+	testDir := mkTempDir()
+	defer os.RemoveAll(testDir)
+
+	mkGitRepo(t, testDir, "https://github.com/githubtraining/github-cheat-sheet.git")
+	os.WriteFile(filepath.Join(testDir, "LICENSE"), []byte("MIT License\n\nCopyright\n\nPermission\n"), 0666)
+	runGit(t, testDir, "add", "LICENSE")
+	runGit(t, testDir, "commit", "-q", "-m", "add license")
+
+	// set config
+	runGit(t, testDir, "config", gitConfigURLTypeName, "bitbucket.org")
+	runGit(t, testDir, "config", gitConfigProtocolName, "http")
+
+	gr, err := newGitRemote(context.Background(), nil, filepath.Join(testDir, "LICENSE"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := gr.remoteURL("master", testLines(3, 4))
+
+	// Expect bitbucket style url and http protocol
+	want := "http://github.com/githubtraining/github-cheat-sheet/src/master/LICENSE#lines-3:4"
+	if got != want {
+		t.Errorf("want '%s', got '%s'\n", want, got)
+	}
+}
+
+func TestRemoteUrlWorktreeAndBare(t *testing.T) {
+	testDir := mkTempDir()
+	defer os.RemoveAll(testDir)
+
+	repoDir := filepath.Join(testDir, "repo")
+	mkGitRepo(t, repoDir, "https://github.com/inouet/gh-open.git")
+	head := runGit(t, repoDir, "rev-parse", "HEAD")
+
+	worktreeDir := filepath.Join(testDir, "worktree")
+	runGit(t, repoDir, "worktree", "add", "-q", "-b", "feature", worktreeDir)
+	os.MkdirAll(filepath.Join(worktreeDir, "docs"), 0777)
+	os.WriteFile(filepath.Join(worktreeDir, "docs", "guide.md"), []byte("guide\n"), 0666)
+	runGit(t, worktreeDir, "add", "docs")
+	runGit(t, worktreeDir, "commit", "-q", "-m", "add docs")
+
+	bareDir := filepath.Join(testDir, "repo.git")
+	runGit(t, testDir, "clone", "-q", "--bare", repoDir, bareDir)
+	runGit(t, bareDir, "remote", "set-url", "origin", "git@github.com:inouet/gh-open.git")
+
+	cases := map[string]struct {
+		path    string
+		gitDir  string
+		branch  string
+		lines   []LineRange
+		want    string
+		wantErr error
+	}{
+		"worktree-file": {
+			path:   filepath.Join(worktreeDir, "README.md"),
+			branch: head,
+			want:   "https://github.com/inouet/gh-open/tree/" + head + "/README.md",
+		},
+		"worktree-subdir": {
+			path:   filepath.Join(worktreeDir, "docs"),
+			branch: "feature",
+			want:   "https://github.com/inouet/gh-open/tree/feature/docs",
+		},
+		"bare-root": {
+			path: bareDir,
+			want: "https://github.com/inouet/gh-open",
+		},
+		"bare-tree-path": {
+			path:   filepath.Join(bareDir, "README.md"),
+			branch: "master",
+			want:   "https://github.com/inouet/gh-open/tree/master/README.md",
+		},
+		"bare-lines": {
+			path:   filepath.Join(bareDir, "README.md"),
+			branch: "master",
+			lines:  testLines(2, 3),
+			want:   "https://github.com/inouet/gh-open/tree/master/README.md#L2-L3",
+		},
+		"bare-out-of-range": {
+			path:    filepath.Join(bareDir, "README.md"),
+			branch:  "master",
+			lines:   testLines(99, 0),
+			wantErr: ErrInvalidLine,
+		},
+		"bare-not-exist": {
+			path:    filepath.Join(bareDir, "typo.go"),
+			branch:  "master",
+			wantErr: ErrNotExist,
+		},
+		"git-dir-env": {
+			path:   "README.md",
+			gitDir: "repo.git",
+			branch: "master",
+			want:   "https://github.com/inouet/gh-open/tree/master/README.md",
+		},
+	}
+
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	os.Chdir(testDir)
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			if c.gitDir != "" {
+				t.Setenv("GIT_DIR", c.gitDir)
+			}
+			gr, err := newGitRemote(context.Background(), nil, c.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := gr.remoteURL(c.branch, c.lines)
+			if c.wantErr != nil {
+				if !errors.Is(err, c.wantErr) {
+					t.Errorf("want %v, got %v\n", c.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != c.want {
+				t.Errorf("want '%s', got '%s'\n", c.want, got)
+			}
+		})
+	}
+}
+
+func TestRemoteUrlUnpushed(t *testing.T) {
+	testDir := mkTempDir()
+	defer os.RemoveAll(testDir)
+
+	mkGitRepo(t, testDir, "https://github.com/inouet/gh-open.git")
+	pushed := runGit(t, testDir, "rev-parse", "HEAD")
+	runGit(t, testDir, "update-ref", "refs/remotes/origin/master", pushed)
+	runGit(t, testDir, "branch", "-q", "--set-upstream-to=origin/master")
+
+	gr, err := newGitRemote(context.Background(), nil, filepath.Join(testDir, "README.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "https://github.com/inouet/gh-open/tree/" + pushed + "/README.md"
+	got, _ := gr.remoteURL("", nil)
+	if got != want {
+		t.Errorf("pushed want '%s', got '%s'\n", want, got)
+	}
+
+	// Local only commit falls back to the merge-base with the upstream
+	runGit(t, testDir, "commit", "-q", "--allow-empty", "-m", "local")
+	gr, err = newGitRemote(context.Background(), nil, filepath.Join(testDir, "README.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ = gr.remoteURL("", nil)
+	if got != want {
+		t.Errorf("unpushed want '%s', got '%s'\n", want, got)
+	}
+
+	gr.requirePushed = true
+	_, err = gr.remoteURL("", nil)
+	if err == nil || !strings.Contains(err.Error(), "is not pushed to origin") {
+		t.Errorf("require-pushed want error, got '%v'\n", err)
+	}
+}
+
+func TestRemoteUrlColumns(t *testing.T) {
+	testDir := mkTempDir()
+	defer os.RemoveAll(testDir)
+
+	mkGitRepo(t, testDir, "https://github.com/inouet/gh-open.git")
+	head := runGit(t, testDir, "rev-parse", "HEAD")
+	lines := []LineRange{{Start: 2, StartCol: 2, End: 3, EndCol: 4}}
+
+	cases := map[string]struct {
+		urlType     string
+		want        string
+		wantWarning string
+	}{
+		"github": {want: "/README.md#L2C2-L3C4"},
+		"gitlab": {urlType: "gitlab.com", want: "/README.md#L2-3", wantWarning: "gitlab.com does not support columns"},
+		"gitea":  {urlType: "gitea.com", want: "/README.md#L2-L3", wantWarning: "gitea.com does not support columns"},
+	}
+	for name, c := range cases {
+		runGit(t, testDir, "config", gitConfigURLTypeName, c.urlType)
+		gr, err := newGitRemote(context.Background(), nil, filepath.Join(testDir, "README.md"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var warnings []string
+		gr.warn = func(msg string) { warnings = append(warnings, msg) }
+		got, err := gr.remoteURL(head, lines)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasSuffix(got, c.want) {
+			t.Errorf("%s want '...%s', got '%s'\n", name, c.want, got)
+		}
+		if c.wantWarning == "" && len(warnings) != 0 || c.wantWarning != "" && (len(warnings) != 1 || !strings.Contains(warnings[0], c.wantWarning)) {
+			t.Errorf("%s want the warning '%s', got %v\n", name, c.wantWarning, warnings)
+		}
+	}
+}
+
+func TestNewGitRemote(t *testing.T) {
+
+	emptyDir := mkTempDir()
+	defer os.RemoveAll(emptyDir)
+
+	cases := []struct {
+		path    string
+		want    string
+		wantErr error
+	}{
+		{
+			path:    emptyDir,
+			wantErr: errors.New("not a git repository (or any of the parent directories)"),
+		},
+	}
+
+	for _, c := range cases {
+		_, err := newGitRemote(context.Background(), nil, c.path)
+		if c.wantErr != nil {
+			if err == nil {
+				t.Errorf("want '%s', got '%s'\n", c.wantErr.Error(), err)
+				continue
+			}
+			if c.wantErr.Error() != err.Error() {
+				t.Errorf("want '%s', got '%s'\n", c.wantErr.Error(), err.Error())
+			}
+		}
+	}
+}
+
+func TestRelativePath(t *testing.T) {
+	testDir := mkTempDir()
+	defer os.RemoveAll(testDir)
+
+	subDir := "foo/bar/baz"
+	os.MkdirAll(testDir+"/"+subDir, 0777)
+
+	cases := []struct {
+		path1 string
+		path2 string
+		want  string
+	}{
+		{
+			path1: testDir,
+			path2: testDir + "/" + subDir,
+			want:  subDir,
+		},
+		{
+			path1: testDir,
+			path2: testDir,
+			want:  "", // same directory return ""
+		},
+		{
+			path1: testDir,
+			path2: testDir + "/" + subDir + "/not-exist.txt",
+			want:  subDir + "/not-exist.txt",
+		},
+	}
+	for _, c := range cases {
+		got, _ := relativePath(c.path1, c.path2)
+		if got != c.want {
+			t.Errorf("want '%s', got '%s'\n", c.want, got)
+		}
+	}
+}
+
+func TestGetLineOption(t *testing.T) {
+	cases := []struct {
+		input     string
+		wantLines []LineRange
+		wantErr   bool
+	}{
+		{input: "", wantLines: nil, wantErr: false},
+		{input: "3", wantLines: []LineRange{{Start: 3}}, wantErr: false},
+		{input: "3-10", wantLines: []LineRange{{Start: 3, End: 10}}, wantErr: false},
+		{input: "3-", wantLines: nil, wantErr: true},
+		{input: "10:5-12:20", wantLines: []LineRange{{Start: 10, StartCol: 5, End: 12, EndCol: 20}}, wantErr: false},
+		{input: "10:5", wantLines: []LineRange{{Start: 10, StartCol: 5}}, wantErr: false},
+		{input: "10-20,30,40-45", wantLines: []LineRange{{Start: 10, End: 20}, {Start: 30}, {Start: 40, End: 45}}, wantErr: false},
+		{input: "20-10", wantLines: nil, wantErr: true},
+		{input: "0", wantLines: nil, wantErr: true},
+		{input: "10:20-10:5", wantLines: nil, wantErr: true},
+		{input: "10,", wantLines: nil, wantErr: true},
+	}
+	for _, c := range cases {
+		lines, err := ParseLines(c.input)
+		if c.wantErr != (err != nil) {
+			t.Errorf("'%s' wantErr %v, got %v\n", c.input, c.wantErr, err)
+		}
+		if !reflect.DeepEqual(lines, c.wantLines) {
+			t.Errorf("'%s' want %v, got %v\n", c.input, c.wantLines, lines)
+		}
+	}
+}
+
+func TestCheckLineRanges(t *testing.T) {
+	testDir := mkTempDir()
+	defer os.RemoveAll(testDir)
+
+	mkGitRepo(t, testDir, "https://github.com/inouet/gh-open.git")
+	gr, err := newGitRemote(context.Background(), nil, filepath.Join(testDir, "README.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := gr.checkLineRanges([]LineRange{{Start: 1, End: 3}}); err != nil {
+		t.Errorf("want nil, got '%v'\n", err)
+	}
+	if err := gr.checkLineRanges([]LineRange{{Start: 2, End: 4}}); err == nil {
+		t.Errorf("want out of range error, got nil\n")
+	}
+}
+
+func TestParseRemoteURL(t *testing.T) {
+	cases := []struct {
+		remote string
+		want   string
+	}{
+		{remote: "git@github.com:inouet/gh-open.git", want: "https://github.com/inouet/gh-open"},
+		{remote: "org-3324601@github.com:inouet/gh-open.git", want: "https://github.com/inouet/gh-open"},
+		{remote: "https://org@dev.azure.com/org/project/_git/repo", want: "https://dev.azure.com/org/project/_git/repo"},
+		{remote: "git@ssh.dev.azure.com:v3/org/project/repo", want: "https://dev.azure.com/org/project/_git/repo"},
+		{remote: "org@vs-ssh.visualstudio.com:v3/org/project/repo", want: "https://org.visualstudio.com/project/_git/repo"},
+	}
+	for _, c := range cases {
+		got, err := parseRemoteURL(c.remote, "https")
+		if err != nil {
+			t.Fatalf("%s: %v", c.remote, err)
+		}
+		if got.String() != c.want {
+			t.Errorf("want '%s', got '%s'\n", c.want, got.String())
+		}
+	}
+}
+
+// TestParseSSHRemoteURL tests if SSH format remote URLs can be parsed correctly
+func TestParseSSHRemoteURL(t *testing.T) {
+	// Standard SSH URLs
+	standardCases := []struct {
+		name       string
+		remoteURL  string
+		wantHost   string
+		wantUser   string
+		wantRepo   string
+		shouldFail bool
+	}{
+		{
+			name:      "standard ssh format",
+			remoteURL: "git@github.com:inouet/gh-open.git",
+			wantHost:  "github.com",
+			wantUser:  "inouet",
+			wantRepo:  "gh-open",
+		},
+		{
+			name:      "standard ssh format without .git",
+			remoteURL: "git@github.com:inouet/gh-open",
+			wantHost:  "github.com",
+			wantUser:  "inouet",
+			wantRepo:  "gh-open",
+		},
+		{
+			name:      "gitlab ssh format",
+			remoteURL: "git@gitlab.com:username/repository.git",
+			wantHost:  "gitlab.com",
+			wantUser:  "username",
+			wantRepo:  "repository",
+		},
+		{
+			name:      "bitbucket ssh format",
+			remoteURL: "git@bitbucket.org:username/repository.git",
+			wantHost:  "bitbucket.org",
+			wantUser:  "username",
+			wantRepo:  "repository",
+		},
+	}
+
+	// Test standard SSH URLs with vcsurl.Parse
+	for _, c := range standardCases {
+		t.Run(c.name, func(t *testing.T) {
+			info, err := vcsurl.Parse(c.remoteURL)
+
+			if c.shouldFail {
+				if err == nil {
+					t.Errorf("expected error but got none for %s", c.remoteURL)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("failed to parse %s: %v", c.remoteURL, err)
+			}
+
+			if string(info.Host) != c.wantHost {
+				t.Errorf("host: want %s, got %s", c.wantHost, info.Host)
+			}
+
+			if info.Username != c.wantUser {
+				t.Errorf("username: want %s, got %s", c.wantUser, info.Username)
+			}
+
+			if info.Name != c.wantRepo {
+				t.Errorf("repo name: want %s, got %s", c.wantRepo, info.Name)
+			}
+
+			// Check if a correct web URL can be generated from the successfully parsed URL
+			expectedWebURL := "https://" + c.wantHost + "/" + c.wantUser + "/" + c.wantRepo
+			host := string(info.Host)
+			fullName := info.FullName
+			webURL := "https://" + host + "/" + fullName
+
+			if webURL != expectedWebURL {
+				t.Errorf("web URL: want %s, got %s", expectedWebURL, webURL)
+			}
+		})
+	}
+
+	// Organization format SSH URLs
+	orgCases := []struct {
+		name       string
+		remoteURL  string
+		wantHost   string
+		wantUser   string
+		wantRepo   string
+		shouldFail bool
+	}{
+		{
+			name:      "organization format with ID",
+			remoteURL: "org-3324601@github.com:complex/repo-name.git",
+			wantHost:  "github.com",
+			wantUser:  "complex",
+			wantRepo:  "repo-name",
+		},
+		{
+			name:      "complex organization name",
+			remoteURL: "org-complex_name-123@github.com:complex/repo-name.git",
+			wantHost:  "github.com",
+			wantUser:  "complex",
+			wantRepo:  "repo-name",
+		},
+	}
+
+	// Test organization format SSH URLs with custom function
+	for _, c := range orgCases {
+		t.Run(c.name, func(t *testing.T) {
+			host, username, repo, err := parseSSHRemoteURL(c.remoteURL)
+
+			if c.shouldFail {
+				if err == nil {
+					t.Errorf("expected error but got none for %s", c.remoteURL)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("failed to parse %s: %v", c.remoteURL, err)
+			}
+
+			if host != c.wantHost {
+				t.Errorf("host: want %s, got %s", c.wantHost, host)
+			}
+
+			if username != c.wantUser {
+				t.Errorf("username: want %s, got %s", c.wantUser, username)
+			}
+
+			if repo != c.wantRepo {
+				t.Errorf("repo name: want %s, got %s", c.wantRepo, repo)
+			}
+
+			// Check if a correct web URL can be generated from the successfully parsed URL
+			expectedWebURL := "https://" + c.wantHost + "/" + c.wantUser + "/" + c.wantRepo
+			webURL := "https://" + host + "/" + username + "/" + repo
+
+			if webURL != expectedWebURL {
+				t.Errorf("web URL: want %s, got %s", expectedWebURL, webURL)
+			}
+		})
+	}
+}
+
+func TestSplitRepoPath(t *testing.T) {
+	cases := []struct {
+		input     string
+		wantOwner string
+		wantRepo  string
+	}{
+		{input: "/inouet/gh-open", wantOwner: "inouet", wantRepo: "gh-open"},
+		{input: "/group/subgroup/repo", wantOwner: "group/subgroup", wantRepo: "repo"},
+		{input: "/org/project/_git/repo", wantOwner: "org/project", wantRepo: "repo"},
+		{input: "/gocloud", wantOwner: "", wantRepo: "gocloud"},
+	}
+	for _, c := range cases {
+		owner, repo := splitRepoPath(c.input)
+		if owner != c.wantOwner || repo != c.wantRepo {
+			t.Errorf("'%s' want '%s' '%s', got '%s' '%s'\n", c.input, c.wantOwner, c.wantRepo, owner, repo)
+		}
+	}
+}
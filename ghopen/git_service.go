@@ -0,0 +1,215 @@
+package ghopen
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	googlesourceRegexp = regexp.MustCompile("^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?\\.googlesource\\.com$")
+)
+
+type buildURLFunc func(url url.URL, filePath string, ref gitRef, lines []LineRange) string
+
+// buildGithubURL build URL for Github
+//   Format: https://github.com/<user>/<repos>/tree/<branch>/path/to/file.txt#L10-L20
+//           https://github.com/<user>/<repos>/tree/<branch>/path/to/file.txt#L10C5-L12C20
+func buildGithubURL(baseURL url.URL, filePath string, ref gitRef, lines []LineRange) string {
+	filePath = strings.TrimLeft(filePath, "/")
+
+	lineStr := ""
+	if len(lines) > 0 {
+		l := lines[0]
+		lineStr = fmt.Sprintf("L%d", l.Start)
+		if l.StartCol != 0 {
+			lineStr = lineStr + fmt.Sprintf("C%d", l.StartCol)
+		}
+		if l.End != 0 {
+			lineStr = lineStr + fmt.Sprintf("-L%d", l.End)
+			if l.EndCol != 0 {
+				lineStr = lineStr + fmt.Sprintf("C%d", l.EndCol)
+			}
+		}
+	}
+	baseURL.Path = fmt.Sprintf("%s/tree/%s/%s", baseURL.Path, ref.name, filePath)
+	baseURL.Fragment = lineStr
+
+	return baseURL.String()
+}
+
+// buildBitbucketURL build URL for bitbucket
+//   Format: https://bitbucket.org/<user>/<repos>/src/<branch>/file.txt#lines-10:20,30
+func buildBitbucketURL(baseURL url.URL, filePath string, ref gitRef, lines []LineRange) string {
+	filePath = strings.TrimLeft(filePath, "/")
+
+	var ranges []string
+	for _, l := range lines {
+		rangeStr := fmt.Sprintf("%d", l.Start)
+		if l.End != 0 {
+			rangeStr = rangeStr + fmt.Sprintf(":%d", l.End)
+		}
+		ranges = append(ranges, rangeStr)
+	}
+	lineStr := ""
+	if len(ranges) > 0 {
+		lineStr = "lines-" + strings.Join(ranges, ",")
+	}
+	baseURL.Path = fmt.Sprintf("%s/src/%s/%s", baseURL.Path, ref.name, filePath)
+	baseURL.Fragment = lineStr
+	return baseURL.String()
+}
+
+// buildGitlabURL build URL for gitlab
+//  Format: https://gitlab.com/<user>/<repos>/-/blob/<branch>/file.txt#L10-20
+func buildGitlabURL(baseURL url.URL, filePath string, ref gitRef, lines []LineRange) string {
+	filePath = strings.TrimLeft(filePath, "/")
+
+	lineStr := ""
+	if len(lines) > 0 {
+		lineStr = fmt.Sprintf("L%d", lines[0].Start)
+		if lines[0].End != 0 {
+			lineStr = lineStr + fmt.Sprintf("-%d", lines[0].End)
+		}
+	}
+	baseURL.Path = fmt.Sprintf("%s/-/blob/%s/%s", baseURL.Path, ref.name, filePath)
+	baseURL.Fragment = lineStr
+
+	return baseURL.String()
+}
+
+// buildGooglesourceURL build URL for *.googlesource.com
+//  Format: https://code.googlesource.com/<repos>/+/<branch>/file.txt#2
+func buildGooglesourceURL(baseURL url.URL, filePath string, ref gitRef, lines []LineRange) string {
+	filePath = strings.TrimLeft(filePath, "/")
+
+	lineStr := ""
+	if len(lines) > 0 {
+		lineStr = fmt.Sprintf("%d", lines[0].Start)
+	}
+	baseURL.Path = fmt.Sprintf("%s/+/%s/%s", baseURL.Path, ref.name, filePath)
+	baseURL.Fragment = lineStr
+
+	return baseURL.String()
+}
+
+// buildGiteaURL build URL for Gitea (and Forgejo)
+//  Format: https://gitea.com/<user>/<repos>/src/branch/<branch>/file.txt#L10-L20
+//          https://gitea.com/<user>/<repos>/src/tag/<tag>/file.txt
+//          https://gitea.com/<user>/<repos>/src/commit/<commit>/file.txt
+func buildGiteaURL(baseURL url.URL, filePath string, ref gitRef, lines []LineRange) string {
+	filePath = strings.TrimLeft(filePath, "/")
+
+	lineStr := ""
+	if len(lines) > 0 {
+		lineStr = fmt.Sprintf("L%d", lines[0].Start)
+		if lines[0].End != 0 {
+			lineStr = lineStr + fmt.Sprintf("-L%d", lines[0].End)
+		}
+	}
+	kind := "branch"
+	switch ref.kind {
+	case refKindTag:
+		kind = "tag"
+	case refKindCommit:
+		kind = "commit"
+	}
+	baseURL.Path = fmt.Sprintf("%s/src/%s/%s/%s", baseURL.Path, kind, ref.name, filePath)
+	baseURL.Fragment = lineStr
+
+	return baseURL.String()
+}
+
+// buildAzureURL build URL for Azure DevOps
+//  Format: https://dev.azure.com/<org>/<project>/_git/<repos>?path=/file.txt&version=GB<branch>&line=10&lineEnd=12&lineStartColumn=5&lineEndColumn=20
+//  The version is GB<branch>, GT<tag> or GC<commit>
+func buildAzureURL(baseURL url.URL, filePath string, ref gitRef, lines []LineRange) string {
+	filePath = strings.TrimLeft(filePath, "/")
+
+	version := "GB"
+	switch ref.kind {
+	case refKindTag:
+		version = "GT"
+	case refKindCommit:
+		version = "GC"
+	}
+
+	query := url.Values{}
+	query.Set("path", "/"+filePath)
+	query.Set("version", version+ref.name)
+	if len(lines) > 0 {
+		l := lines[0]
+		startCol := max(l.StartCol, 1)
+		lineEnd, endCol := l.Start, l.EndCol
+		if l.End != 0 {
+			lineEnd = l.End
+		}
+		if endCol == 0 {
+			// Without the end column, the selection ends at the first column of the next line
+			lineEnd, endCol = lineEnd+1, 1
+		}
+		query.Set("line", fmt.Sprintf("%d", l.Start))
+		query.Set("lineEnd", fmt.Sprintf("%d", lineEnd))
+		query.Set("lineStartColumn", fmt.Sprintf("%d", startCol))
+		query.Set("lineEndColumn", fmt.Sprintf("%d", endCol))
+		query.Set("lineStyle", "plain")
+	}
+	baseURL.RawQuery = query.Encode()
+
+	return baseURL.String()
+}
+
+func buildURL(baseURL url.URL, path string, ref gitRef, lines []LineRange, urlType string) (string, error) {
+	buildFunc, err := getGitURLBuilder(baseURL, urlType)
+	if err != nil {
+		return "", err
+	}
+
+	// Only bitbucket can select multiple line ranges
+	if host := serviceHost(baseURL, urlType); len(lines) > 1 && host != "bitbucket.org" {
+		return "", errorf(ErrInvalidLine, "%s does not support multiple line ranges", host)
+	}
+
+	remoteURL := buildFunc(baseURL, path, ref, lines)
+	return remoteURL, nil
+}
+
+// supportsColumns reports whether the URL format selects the columns of the lines (GitHub and Azure DevOps)
+func supportsColumns(baseURL url.URL, urlType string) bool {
+	host := serviceHost(baseURL, urlType)
+	return host == "github.com" || host == "dev.azure.com" || strings.HasSuffix(host, ".visualstudio.com")
+}
+
+// serviceHost returns the host which decides the URL format
+func serviceHost(baseURL url.URL, urlType string) string {
+	if urlType != "" {
+		return urlType
+	}
+	return baseURL.Hostname()
+}
+
+func getGitURLBuilder(baseURL url.URL, urlType string) (buildURLFunc, error) {
+	host := serviceHost(baseURL, urlType)
+	switch host {
+	case "github.com":
+		return buildGithubURL, nil
+	case "gitlab.com":
+		return buildGitlabURL, nil
+	case "bitbucket.org":
+		return buildBitbucketURL, nil
+	case "gitea.com", "codeberg.org":
+		return buildGiteaURL, nil
+	case "dev.azure.com":
+		return buildAzureURL, nil
+	}
+	switch {
+	// *.googlesource.com (eg: code.googlesource.com)
+	case googlesourceRegexp.MatchString(host):
+		return buildGooglesourceURL, nil
+	// <org>.visualstudio.com (old Azure DevOps domain)
+	case strings.HasSuffix(host, ".visualstudio.com"):
+		return buildAzureURL, nil
+	}
+	return nil, errorf(ErrUnsupportedHost, "unknown git service: '%s'", host)
+}
@@ -0,0 +1,319 @@
+package ghopen
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	githubLinesRegexp    = regexp.MustCompile(`^L(\d+)(?:C(\d+))?(?:-L(\d+)(?:C(\d+))?)?$`)
+	gitlabLinesRegexp    = regexp.MustCompile(`^L(\d+)(?:-(\d+))?$`)
+	bitbucketRangeRegexp = regexp.MustCompile(`^(\d+)(?::(\d+))?$`)
+)
+
+// serviceURL is a web URL of a file, parsed by the inverse of the URL builders
+type serviceURL struct {
+	base    url.URL // The repository (eg: https://github.com/inouet/gh-open)
+	refPath string  // <ref>/<path> when the ref may contain slashes, split by splitRefPath
+	ref     gitRef  // The ref when the URL separates it from the path
+	path    string
+	lines   []LineRange
+}
+
+type parseURLFunc func(u url.URL) (serviceURL, error)
+
+// parseGithubURL parses the URL made by buildGithubURL, blob/ is accepted as well as tree/
+func parseGithubURL(u url.URL) (serviceURL, error) {
+	s, err := splitServicePath(u, "/tree/", "/blob/")
+	if err != nil {
+		return s, err
+	}
+	s.lines, err = parseFragmentLines(githubLinesRegexp, u.Fragment)
+	return s, err
+}
+
+// parseGitlabURL parses the URL made by buildGitlabURL
+func parseGitlabURL(u url.URL) (serviceURL, error) {
+	s, err := splitServicePath(u, "/-/blob/", "/-/tree/")
+	if err != nil {
+		return s, err
+	}
+	s.lines, err = parseFragmentLines(gitlabLinesRegexp, u.Fragment)
+	return s, err
+}
+
+// parseBitbucketURL parses the URL made by buildBitbucketURL
+//   #lines-10:20,30 => 10-20, 30
+func parseBitbucketURL(u url.URL) (serviceURL, error) {
+	s, err := splitServicePath(u, "/src/")
+	if err != nil || u.Fragment == "" {
+		return s, err
+	}
+	ranges, found := strings.CutPrefix(u.Fragment, "lines-")
+	if !found {
+		return s, nil
+	}
+	for _, r := range strings.Split(ranges, ",") {
+		m := bitbucketRangeRegexp.FindStringSubmatch(r)
+		if m == nil {
+			return serviceURL{}, errorf(ErrInvalidLine, "invalid lines: #%s", u.Fragment)
+		}
+		s.lines = append(s.lines, LineRange{Start: atoi(m[1]), End: atoi(m[2])})
+	}
+	return s, nil
+}
+
+// parseGooglesourceURL parses the URL made by buildGooglesourceURL
+func parseGooglesourceURL(u url.URL) (serviceURL, error) {
+	s, err := splitServicePath(u, "/+/")
+	if err != nil || u.Fragment == "" {
+		return s, err
+	}
+	line, err := strconv.Atoi(u.Fragment)
+	if err != nil {
+		return serviceURL{}, errorf(ErrInvalidLine, "invalid lines: #%s", u.Fragment)
+	}
+	s.lines = []LineRange{{Start: line}}
+	return s, nil
+}
+
+// parseGiteaURL parses the URL made by buildGiteaURL, the kind of the ref is in the path
+func parseGiteaURL(u url.URL) (serviceURL, error) {
+	for _, kind := range []refKind{refKindBranch, refKindTag, refKindCommit} {
+		s, err := splitServicePath(u, "/src/"+kind.String()+"/")
+		if err != nil {
+			continue
+		}
+		s.ref.kind = kind
+		s.lines, err = parseFragmentLines(githubLinesRegexp, u.Fragment)
+		return s, err
+	}
+	return serviceURL{}, errorf(ErrUnsupportedHost, "not a file url of %s: %s", u.Hostname(), u.String())
+}
+
+// parseAzureURL parses the URL made by buildAzureURL, the path and the ref are in the query
+func parseAzureURL(u url.URL) (serviceURL, error) {
+	if !strings.Contains(u.Path, "/_git/") {
+		return serviceURL{}, errorf(ErrUnsupportedHost, "not a file url of Azure DevOps: %s", u.String())
+	}
+	query := u.Query()
+	s := serviceURL{base: u, path: strings.TrimLeft(query.Get("path"), "/")}
+	s.base.RawQuery, s.base.Fragment = "", ""
+
+	version := query.Get("version")
+	if len(version) > 2 {
+		kinds := map[string]refKind{"GB": refKindBranch, "GT": refKindTag, "GC": refKindCommit}
+		s.ref = gitRef{version[2:], kinds[version[:2]]}
+	}
+
+	if query.Get("line") == "" {
+		return s, nil
+	}
+	l := LineRange{
+		Start:    atoi(query.Get("line")),
+		End:      atoi(query.Get("lineEnd")),
+		StartCol: atoi(query.Get("lineStartColumn")),
+		EndCol:   atoi(query.Get("lineEndColumn")),
+	}
+	if l.Start == 0 {
+		return serviceURL{}, errorf(ErrInvalidLine, "invalid line: %s", query.Get("line"))
+	}
+	if l.StartCol == 1 && l.EndCol == 1 && l.End > l.Start {
+		// Whole lines, which end at the first column of the next line
+		l.StartCol, l.EndCol, l.End = 0, 0, l.End-1
+	}
+	if l.End == l.Start && l.EndCol == 0 {
+		l.End = 0
+	}
+	s.lines = []LineRange{l}
+	return s, nil
+}
+
+// splitServicePath splits the path of the URL at the first marker into the repository and <ref>/<path>
+//   /inouet/gh-open/tree/master/main.go => /inouet/gh-open, master/main.go
+func splitServicePath(u url.URL, markers ...string) (serviceURL, error) {
+	for _, marker := range markers {
+		i := strings.Index(u.Path, marker)
+		if i <= 0 {
+			continue
+		}
+		base := u
+		base.Path, base.RawPath, base.RawQuery, base.Fragment = u.Path[:i], "", "", ""
+		return serviceURL{base: base, refPath: strings.TrimSuffix(u.Path[i+len(marker):], "/")}, nil
+	}
+	return serviceURL{}, errorf(ErrUnsupportedHost, "not a file url of %s: %s", u.Hostname(), u.String())
+}
+
+// parseFragmentLines parses the line range of the fragment by the regular expression
+// of the start line and column, and the end line and column
+func parseFragmentLines(re *regexp.Regexp, fragment string) ([]LineRange, error) {
+	if fragment == "" {
+		return nil, nil
+	}
+	m := re.FindStringSubmatch(fragment)
+	if m == nil {
+		return nil, errorf(ErrInvalidLine, "invalid lines: #%s", fragment)
+	}
+	l := LineRange{Start: atoi(m[1])}
+	if len(m) == 5 {
+		l.StartCol, l.End, l.EndCol = atoi(m[2]), atoi(m[3]), atoi(m[4])
+	} else {
+		l.End = atoi(m[2])
+	}
+	return []LineRange{l}, nil
+}
+
+// atoi returns 0 for empty or invalid numbers
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// getGitURLParser returns the inverse of the URL builder of the host.
+// For an unknown host without the url type (eg: self hosted), the format is guessed from the path.
+func getGitURLParser(u url.URL, urlType string) (parseURLFunc, error) {
+	host := serviceHost(u, urlType)
+	switch host {
+	case "github.com":
+		return parseGithubURL, nil
+	case "gitlab.com":
+		return parseGitlabURL, nil
+	case "bitbucket.org":
+		return parseBitbucketURL, nil
+	case "gitea.com", "codeberg.org":
+		return parseGiteaURL, nil
+	case "dev.azure.com":
+		return parseAzureURL, nil
+	}
+	switch {
+	case googlesourceRegexp.MatchString(host):
+		return parseGooglesourceURL, nil
+	case strings.HasSuffix(host, ".visualstudio.com"):
+		return parseAzureURL, nil
+	case urlType != "":
+		return nil, errorf(ErrUnsupportedHost, "unknown git service: '%s'", host)
+	}
+
+	// The most specific markers first, eg: /-/blob/ of GitLab would match /blob/ of GitHub
+	switch p := u.Path; {
+	case strings.Contains(p, "/-/blob/"), strings.Contains(p, "/-/tree/"):
+		return parseGitlabURL, nil
+	case strings.Contains(p, "/_git/"):
+		return parseAzureURL, nil
+	case strings.Contains(p, "/src/branch/"), strings.Contains(p, "/src/tag/"), strings.Contains(p, "/src/commit/"):
+		return parseGiteaURL, nil
+	case strings.Contains(p, "/src/"):
+		return parseBitbucketURL, nil
+	case strings.Contains(p, "/+/"):
+		return parseGooglesourceURL, nil
+	case strings.Contains(p, "/tree/"), strings.Contains(p, "/blob/"):
+		return parseGithubURL, nil
+	}
+	return nil, errorf(ErrUnsupportedHost, "unknown git service: '%s', try git config gh-open.urltype", host)
+}
+
+// parseServiceURL parses the web URL of a file, the url type selects the format as gh-open.urltype
+func parseServiceURL(rawURL, urlType string) (serviceURL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return serviceURL{}, errorf(ErrUnsupportedHost, "not a web url: %s", rawURL)
+	}
+	parse, err := getGitURLParser(*u, urlType)
+	if err != nil {
+		return serviceURL{}, err
+	}
+	s, err := parse(*u)
+	if err != nil {
+		return serviceURL{}, err
+	}
+	s.base.Path = strings.TrimSuffix(s.base.Path, ".git")
+	if s.refPath != "" {
+		// Without the refs of the repository, the ref is the first segment
+		s.splitRefPath(func(string) bool { return false })
+	}
+	return s, nil
+}
+
+// splitRefPath splits <ref>/<path> at the longest ref prefix known by isRef,
+// or after the first segment
+//   feature/login/main.go => feature/login, main.go (when feature/login is a branch)
+func (s *serviceURL) splitRefPath(isRef func(name string) bool) {
+	segments := strings.Split(s.refPath, "/")
+	split := 1
+	for i := len(segments); i > 1; i-- {
+		if isRef(strings.Join(segments[:i], "/")) {
+			split = i
+			break
+		}
+	}
+	name := strings.Join(segments[:split], "/")
+	kind := s.ref.kind
+	if commitHashRegexp.MatchString(name) {
+		kind = refKindCommit
+	}
+	s.ref = gitRef{name, kind}
+	s.path = strings.Join(segments[split:], "/")
+}
+
+// location returns the location of the parsed URL
+func (s serviceURL) location(rawURL string) Location {
+	owner, repo := splitRepoPath(s.base.Path)
+	loc := Location{Host: s.base.Host, Owner: owner, Repo: repo, Path: s.path, Lines: s.lines, URL: rawURL}
+	if s.ref.name != "" {
+		loc.Ref = s.ref.name
+		loc.RefKind = s.ref.kind.String()
+	}
+	return loc
+}
+
+// ParseURL parses the web URL of a file (or a directory) as the URLs made by Resolve,
+// urlType selects the format of a self hosted service as git config gh-open.urltype (eg: github.com).
+// A ref containing slashes can not be told from the path without the repository,
+// the first segment is taken as the ref.
+func ParseURL(rawURL, urlType string) (Location, error) {
+	s, err := parseServiceURL(rawURL, urlType)
+	if err != nil {
+		return Location{}, err
+	}
+	return s.location(rawURL), nil
+}
+
+// ConvertURL rebuilds the web URL of a file for another host (eg: a mirror), keeping the ref, the path and the lines.
+// repo is the repository path on the host (eg: org/name), the path of the URL when empty.
+// The ref is taken as a branch unless the URL tells a tag or a commit (Gitea and Azure DevOps) or it is a commit hash.
+func ConvertURL(rawURL, host, repo string) (Location, error) {
+	s, err := parseServiceURL(rawURL, "")
+	if err != nil {
+		return Location{}, err
+	}
+	s.base.Host = host
+	if repo != "" {
+		s.base.Path = "/" + strings.Trim(repo, "/")
+	}
+	s.base.User = nil
+	s.base.Path = convertRepoPath(s.base.Path, host)
+
+	remoteURL, err := buildURL(s.base, s.path, s.ref, s.lines, "")
+	if err != nil {
+		return Location{}, err
+	}
+	return s.location(remoteURL), nil
+}
+
+// convertRepoPath adds or removes /_git/ of Azure DevOps in the repository path
+//   /org/project/repo => /org/project/_git/repo (Azure DevOps)
+//   /org/project/_git/repo => /org/project/repo (others)
+func convertRepoPath(repoPath, host string) string {
+	azure := host == "dev.azure.com" || strings.HasSuffix(host, ".visualstudio.com")
+	hasGit := strings.Contains(repoPath, "/_git/")
+	switch {
+	case azure && !hasGit:
+		i := strings.LastIndex(repoPath, "/")
+		return repoPath[:i] + "/_git" + repoPath[i:]
+	case !azure && hasGit:
+		return strings.Replace(repoPath, "/_git/", "/", 1)
+	}
+	return repoPath
+}
@@ -0,0 +1,204 @@
+package ghopen
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestParseURL(t *testing.T) {
+	commit := "695895662d96bac8d94fd71dc9d2dec534c8e494"
+	cases := []struct {
+		input   string
+		urlType string
+		want    Location
+		wantErr error
+	}{
+		{
+			input: "https://github.com/inouet/gh-open/blob/" + commit + "/main.go#L10-L20",
+			want:  Location{Host: "github.com", Owner: "inouet", Repo: "gh-open", Ref: commit, RefKind: "commit", Path: "main.go", Lines: []LineRange{{Start: 10, End: 20}}},
+		},
+		{
+			input: "https://github.com/inouet/gh-open/tree/master/ghopen/git_remote.go#L10C5-L12C20",
+			want:  Location{Host: "github.com", Owner: "inouet", Repo: "gh-open", Ref: "master", RefKind: "branch", Path: "ghopen/git_remote.go", Lines: []LineRange{{Start: 10, StartCol: 5, End: 12, EndCol: 20}}},
+		},
+		{
+			input: "https://gitlab.com/group/subgroup/repo/-/blob/main/main.go#L10-12",
+			want:  Location{Host: "gitlab.com", Owner: "group/subgroup", Repo: "repo", Ref: "main", RefKind: "branch", Path: "main.go", Lines: []LineRange{{Start: 10, End: 12}}},
+		},
+		{
+			input: "https://bitbucket.org/inouet/gh-open/src/main/main.go#lines-10:20,30",
+			want:  Location{Host: "bitbucket.org", Owner: "inouet", Repo: "gh-open", Ref: "main", RefKind: "branch", Path: "main.go", Lines: []LineRange{{Start: 10, End: 20}, {Start: 30}}},
+		},
+		{
+			input: "https://go.googlesource.com/tools/+/master/README.md#2",
+			want:  Location{Host: "go.googlesource.com", Owner: "", Repo: "tools", Ref: "master", RefKind: "branch", Path: "README.md", Lines: []LineRange{{Start: 2}}},
+		},
+		{
+			input: "https://codeberg.org/inouet/gh-open/src/tag/v1.0.0/main.go#L10-L20",
+			want:  Location{Host: "codeberg.org", Owner: "inouet", Repo: "gh-open", Ref: "v1.0.0", RefKind: "tag", Path: "main.go", Lines: []LineRange{{Start: 10, End: 20}}},
+		},
+		{
+			input: "https://dev.azure.com/org/project/_git/gh-open?line=10&lineEnd=21&lineEndColumn=1&lineStartColumn=1&lineStyle=plain&path=%2Fmain.go&version=GTv1.0.0",
+			want:  Location{Host: "dev.azure.com", Owner: "org/project", Repo: "gh-open", Ref: "v1.0.0", RefKind: "tag", Path: "main.go", Lines: []LineRange{{Start: 10, End: 20}}},
+		},
+		{
+			input: "https://git.example.com/inouet/gh-open/-/blob/main/main.go#L3",
+			want:  Location{Host: "git.example.com", Owner: "inouet", Repo: "gh-open", Ref: "main", RefKind: "branch", Path: "main.go", Lines: []LineRange{{Start: 3}}},
+		},
+		{
+			input:   "https://git.example.com/inouet/gh-open/src/main/main.go",
+			urlType: "github.com",
+			wantErr: ErrUnsupportedHost,
+		},
+		{
+			input:   "https://github.com/inouet/gh-open/tree/main/main.go#L10-20",
+			wantErr: ErrInvalidLine,
+		},
+		{
+			input:   "https://github.com/inouet/gh-open",
+			wantErr: ErrUnsupportedHost,
+		},
+	}
+	for _, c := range cases {
+		got, err := ParseURL(c.input, c.urlType)
+		if c.wantErr != nil {
+			if !errors.Is(err, c.wantErr) {
+				t.Errorf("'%s' want %v, got %v\n", c.input, c.wantErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("'%s' %v\n", c.input, err)
+			continue
+		}
+		c.want.URL = c.input
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("'%s' want %+v, got %+v\n", c.input, c.want, got)
+		}
+	}
+}
+
+// TestParseBuiltURL parses the URLs of the builders back
+func TestParseBuiltURL(t *testing.T) {
+	bases := []string{
+		"https://github.com/inouet/gh-open",
+		"https://gitlab.com/inouet/gh-open",
+		"https://bitbucket.org/inouet/gh-open",
+		"https://code.googlesource.com/gh-open",
+		"https://gitea.com/inouet/gh-open",
+		"https://dev.azure.com/org/project/_git/gh-open",
+		"https://org.visualstudio.com/project/_git/gh-open",
+	}
+	refs := []gitRef{
+		{"main", refKindBranch},
+		{"v1.0.0", refKindTag},
+		{"695895662d96bac8d94fd71dc9d2dec534c8e494", refKindCommit},
+	}
+	for _, base := range bases {
+		baseURL, _ := url.Parse(base)
+		for _, ref := range refs {
+			lines := []LineRange{{Start: 10, End: 20}}
+			if baseURL.Host == "code.googlesource.com" {
+				lines = []LineRange{{Start: 10}}
+			}
+			built, err := buildURL(*baseURL, "pkg/main.go", ref, lines, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			s, err := parseServiceURL(built, "")
+			if err != nil {
+				t.Errorf("'%s' %v\n", built, err)
+				continue
+			}
+			if s.base.String() != base || s.ref.name != ref.name || s.path != "pkg/main.go" || !reflect.DeepEqual(s.lines, lines) {
+				t.Errorf("'%s' parsed as %s %s %s %v\n", built, s.base.String(), s.ref.name, s.path, s.lines)
+			}
+		}
+	}
+}
+
+func TestSplitRefPath(t *testing.T) {
+	refs := map[string]bool{"feature/login": true, "feature": true}
+	cases := []struct {
+		refPath  string
+		wantRef  string
+		wantPath string
+	}{
+		{refPath: "feature/login/main.go", wantRef: "feature/login", wantPath: "main.go"},
+		{refPath: "feature/login", wantRef: "feature/login", wantPath: ""},
+		{refPath: "main/pkg/main.go", wantRef: "main", wantPath: "pkg/main.go"},
+	}
+	for _, c := range cases {
+		s := serviceURL{refPath: c.refPath}
+		s.splitRefPath(func(name string) bool { return refs[name] })
+		if s.ref.name != c.wantRef || s.path != c.wantPath {
+			t.Errorf("'%s' want %s %s, got %s %s\n", c.refPath, c.wantRef, c.wantPath, s.ref.name, s.path)
+		}
+	}
+}
+
+func TestConvertURL(t *testing.T) {
+	cases := []struct {
+		input   string
+		host    string
+		repo    string
+		want    string
+		wantErr error
+	}{
+		{
+			input: "https://gitlab.com/inouet/gh-open/-/blob/main/main.go#L10-20",
+			host:  "github.com",
+			want:  "https://github.com/inouet/gh-open/tree/main/main.go#L10-L20",
+		},
+		{
+			input: "https://gitlab.com/group/subgroup/gh-open/-/blob/v1.0.0/docs/README.md",
+			host:  "github.com",
+			repo:  "inouet/gh-open",
+			want:  "https://github.com/inouet/gh-open/tree/v1.0.0/docs/README.md",
+		},
+		{
+			input: "https://github.com/inouet/gh-open/blob/695895662d96bac8d94fd71dc9d2dec534c8e494/main.go#L10",
+			host:  "codeberg.org",
+			want:  "https://codeberg.org/inouet/gh-open/src/commit/695895662d96bac8d94fd71dc9d2dec534c8e494/main.go#L10",
+		},
+		{
+			input: "https://github.com/inouet/gh-open/tree/main/main.go#L10C5-L12C20",
+			host:  "dev.azure.com",
+			repo:  "org/project/gh-open",
+			want:  "https://dev.azure.com/org/project/_git/gh-open?line=10&lineEnd=12&lineEndColumn=20&lineStartColumn=5&lineStyle=plain&path=%2Fmain.go&version=GBmain",
+		},
+		{
+			input: "https://dev.azure.com/org/project/_git/gh-open?path=%2Fmain.go&version=GTv1.0.0",
+			host:  "bitbucket.org",
+			want:  "https://bitbucket.org/org/project/gh-open/src/v1.0.0/main.go",
+		},
+		{
+			input:   "https://bitbucket.org/inouet/gh-open/src/main/main.go#lines-10:20,30",
+			host:    "github.com",
+			wantErr: ErrInvalidLine,
+		},
+		{
+			input:   "https://github.com/inouet/gh-open/tree/main/main.go",
+			host:    "example.com",
+			wantErr: ErrUnsupportedHost,
+		},
+	}
+	for _, c := range cases {
+		got, err := ConvertURL(c.input, c.host, c.repo)
+		if c.wantErr != nil {
+			if !errors.Is(err, c.wantErr) {
+				t.Errorf("'%s' want %v, got %v\n", c.input, c.wantErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("'%s' %v\n", c.input, err)
+			continue
+		}
+		if got.URL != c.want {
+			t.Errorf("'%s' want '%s', got '%s'\n", c.input, c.want, got.URL)
+		}
+	}
+}
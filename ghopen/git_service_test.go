@@ -0,0 +1,149 @@
+package ghopen
+
+import (
+	"net/url"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestGetGitURLBuilder(t *testing.T) {
+	cases := []struct {
+		input string
+		want  buildURLFunc
+	}{
+		{input: "https://github.com/", want: buildGithubURL},
+		{input: "https://gitlab.com/", want: buildGitlabURL},
+		{input: "https://bitbucket.org/", want: buildBitbucketURL},
+		{input: "https://code.googlesource.com/", want: buildGooglesourceURL},
+		{input: "https://go.googlesource.com/", want: buildGooglesourceURL},
+		{input: "https://-go.googlesource.com/", want: nil},
+		{input: "https://codeberg.org/", want: buildGiteaURL},
+		{input: "https://dev.azure.com/", want: buildAzureURL},
+		{input: "https://myorg.visualstudio.com/", want: buildAzureURL},
+		{input: "https://google.com/", want: nil},
+	}
+	for _, c := range cases {
+		u, _ := url.Parse(c.input)
+		got, _ := getGitURLBuilder(*u, "")
+
+		wantFunc := getFuncName(c.want)
+		gotFunc := getFuncName(got)
+		if wantFunc != gotFunc {
+			t.Errorf("'%s' want %s, got %s\n", c.input, wantFunc, gotFunc)
+		}
+	}
+}
+
+func TestBuildURLRefKind(t *testing.T) {
+	cases := []struct {
+		base  string
+		ref   gitRef
+		lines []LineRange
+		want  string
+	}{
+		{
+			base: "https://codeberg.org/inouet/gh-open",
+			ref:  gitRef{"main", refKindBranch},
+			want: "https://codeberg.org/inouet/gh-open/src/branch/main/main.go",
+		},
+		{
+			base:  "https://codeberg.org/inouet/gh-open",
+			ref:   gitRef{"v1.0.0", refKindTag},
+			lines: []LineRange{{Start: 10, End: 20}},
+			want:  "https://codeberg.org/inouet/gh-open/src/tag/v1.0.0/main.go#L10-L20",
+		},
+		{
+			base: "https://codeberg.org/inouet/gh-open",
+			ref:  gitRef{"695895662d96bac8d94fd71dc9d2dec534c8e494", refKindCommit},
+			want: "https://codeberg.org/inouet/gh-open/src/commit/695895662d96bac8d94fd71dc9d2dec534c8e494/main.go",
+		},
+		{
+			base: "https://dev.azure.com/org/project/_git/gh-open",
+			ref:  gitRef{"main", refKindBranch},
+			want: "https://dev.azure.com/org/project/_git/gh-open?path=%2Fmain.go&version=GBmain",
+		},
+		{
+			base:  "https://dev.azure.com/org/project/_git/gh-open",
+			ref:   gitRef{"v1.0.0", refKindTag},
+			lines: []LineRange{{Start: 10, End: 20}},
+			want:  "https://dev.azure.com/org/project/_git/gh-open?line=10&lineEnd=21&lineEndColumn=1&lineStartColumn=1&lineStyle=plain&path=%2Fmain.go&version=GTv1.0.0",
+		},
+		{
+			base: "https://github.com/inouet/gh-open",
+			ref:  gitRef{"v1.0.0", refKindTag},
+			want: "https://github.com/inouet/gh-open/tree/v1.0.0/main.go",
+		},
+	}
+	for _, c := range cases {
+		u, _ := url.Parse(c.base)
+		got, err := buildURL(*u, "main.go", c.ref, c.lines, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != c.want {
+			t.Errorf("want '%s', got '%s'\n", c.want, got)
+		}
+	}
+}
+
+func TestBuildURLLines(t *testing.T) {
+	cases := []struct {
+		base    string
+		lines   []LineRange
+		want    string
+		wantErr bool
+	}{
+		{
+			base:  "https://github.com/inouet/gh-open",
+			lines: []LineRange{{Start: 10, StartCol: 5, End: 12, EndCol: 20}},
+			want:  "https://github.com/inouet/gh-open/tree/main/main.go#L10C5-L12C20",
+		},
+		{
+			base:  "https://github.com/inouet/gh-open",
+			lines: []LineRange{{Start: 10, StartCol: 5}},
+			want:  "https://github.com/inouet/gh-open/tree/main/main.go#L10C5",
+		},
+		{
+			base:  "https://gitlab.com/inouet/gh-open",
+			lines: []LineRange{{Start: 10, StartCol: 5, End: 12, EndCol: 20}},
+			want:  "https://gitlab.com/inouet/gh-open/-/blob/main/main.go#L10-12",
+		},
+		{
+			base:  "https://dev.azure.com/org/project/_git/gh-open",
+			lines: []LineRange{{Start: 10, StartCol: 5, End: 12, EndCol: 20}},
+			want:  "https://dev.azure.com/org/project/_git/gh-open?line=10&lineEnd=12&lineEndColumn=20&lineStartColumn=5&lineStyle=plain&path=%2Fmain.go&version=GBmain",
+		},
+		{
+			base:  "https://bitbucket.org/inouet/gh-open",
+			lines: []LineRange{{Start: 10, End: 20}, {Start: 30}, {Start: 40, End: 45}},
+			want:  "https://bitbucket.org/inouet/gh-open/src/main/main.go#lines-10:20,30,40:45",
+		},
+		{
+			base:    "https://github.com/inouet/gh-open",
+			lines:   []LineRange{{Start: 10, End: 20}, {Start: 30}},
+			wantErr: true,
+		},
+	}
+	for _, c := range cases {
+		u, _ := url.Parse(c.base)
+		got, err := buildURL(*u, "main.go", gitRef{"main", refKindBranch}, c.lines, "")
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("'%s' want error, got nil\n", c.base)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != c.want {
+			t.Errorf("want '%s', got '%s'\n", c.want, got)
+		}
+	}
+}
+
+func getFuncName(i interface{}) string {
+	return runtime.FuncForPC(reflect.ValueOf(i).Pointer()).Name()
+}
+
@@ -0,0 +1,139 @@
+package ghopen
+
+import (
+	neturl "net/url"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+type submodule struct {
+	name string
+	path string // Relative path from the superproject top directory
+	url  string // As written in .gitmodules, may be relative to the superproject remote
+}
+
+// resolveSubmodule points the remote at the submodule repository
+// when the object lies inside (or is) a submodule.
+// The ref is the gitlink commit recorded in the superproject when the path crosses into the submodule
+// (the submodule directory, or a submodule which is not checked out), the files in a checked out
+// submodule are linked at its own HEAD.
+func (r *gitRemote) resolveSubmodule() error {
+	superDir, err := r.git.getSuperprojectDir()
+	if err == nil && superDir != "" {
+		return r.resolveFromSuperproject(superDir)
+	}
+
+	// The object is in this repository, but the path may cross into
+	// a submodule which is not checked out (eg: not initialized).
+	submodules, err := r.git.getSubmodules()
+	if err != nil {
+		return nil
+	}
+	relPath := filepath.ToSlash(r.path)
+	for _, s := range submodules {
+		if relPath != s.path && !strings.HasPrefix(relPath, s.path+"/") {
+			continue
+		}
+		commit, err := r.git.getGitlinkHash(s.path)
+		if err != nil {
+			return nil
+		}
+		remote, err := submoduleRemoteURL(r.git, s, "")
+		if err != nil {
+			// Fall back to the gitlink itself in the superproject
+			r.path = s.path
+			r.gitlink = true
+			return nil
+		}
+		r.remote = remote
+		r.commit = commit
+		r.path = strings.TrimPrefix(strings.TrimPrefix(relPath, s.path), "/")
+		return nil
+	}
+	return nil
+}
+
+// resolveFromSuperproject is called when r.git is the top directory of a checked out submodule.
+func (r *gitRemote) resolveFromSuperproject(superDir string) error {
+	superGit, err := newGit(r.git.ctx, r.git.backend, superDir)
+	if err != nil {
+		return err
+	}
+	subPath, err := relativePath(superGit.dir, r.git.dir)
+	if err != nil {
+		return err
+	}
+	subPath = filepath.ToSlash(subPath)
+
+	commit, err := superGit.getGitlinkHash(subPath)
+	if err != nil {
+		// Not committed in the superproject yet, link to the submodule HEAD
+		return nil
+	}
+
+	s := submodule{path: subPath}
+	submodules, _ := superGit.getSubmodules()
+	for _, sm := range submodules {
+		if sm.path == subPath {
+			s = sm
+		}
+	}
+	origin, _ := r.git.getRemoteOriginURL()
+	remote, err := submoduleRemoteURL(superGit, s, origin)
+	if err != nil {
+		// Fall back to the gitlink itself in the superproject
+		r.git = superGit
+		r.path = subPath
+		r.gitlink = true
+		return nil
+	}
+	r.remote = remote
+	if r.path == "" {
+		// The submodule directory is linked at the commit pinned by the superproject
+		r.commit = commit
+	}
+	return nil
+}
+
+// submoduleRemoteURL returns the first url of the submodule which is a web hosted repository.
+// Candidates are the origin of the checked out submodule, the url in .git/config and the url in .gitmodules.
+func submoduleRemoteURL(superGit *git, s submodule, origin string) (string, error) {
+	superRemote, _ := superGit.getRemoteOriginURL()
+	candidates := []string{
+		origin,
+		superGit.getConfig("submodule."+s.name+".url", ""),
+		s.url,
+	}
+	for _, url := range candidates {
+		if url == "" {
+			continue
+		}
+		remote := resolveSubmoduleURL(superRemote, url)
+		if _, err := parseRemoteURL(remote, "https"); err == nil {
+			return remote, nil
+		}
+	}
+	return "", errorf(ErrNoRemote, "no linkable url for submodule: %s", s.path)
+}
+
+// resolveSubmoduleURL resolves ./ or ../ urls against the superproject remote url
+//   e.g. git@github.com:inouet/super.git + ../lib.git => git@github.com:inouet/lib.git
+func resolveSubmoduleURL(base, url string) string {
+	if !strings.HasPrefix(url, "./") && !strings.HasPrefix(url, "../") {
+		return url
+	}
+	if strings.Contains(base, "://") {
+		u, err := neturl.Parse(base)
+		if err != nil {
+			return url
+		}
+		u.Path = path.Join(u.Path, url)
+		return u.String()
+	}
+	// scp-like syntax: [user@]host:path
+	if i := strings.Index(base, ":"); i >= 0 {
+		return base[:i+1] + path.Join(base[i+1:], url)
+	}
+	return url
+}
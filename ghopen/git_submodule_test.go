@@ -0,0 +1,108 @@
+package ghopen
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSubmoduleRemoteUrl(t *testing.T) {
+	testDir := mkTempDir()
+	defer os.RemoveAll(testDir)
+
+	subDir := filepath.Join(testDir, "sub")
+	superDir := filepath.Join(testDir, "super")
+	mkGitRepo(t, subDir, "https://github.com/inouet/sub.git")
+	mkGitRepo(t, superDir, "git@github.com:inouet/super.git")
+	runGit(t, superDir, "-c", "protocol.file.allow=always", "submodule", "add", "-q", subDir, "lib")
+	runGit(t, superDir, "config", "-f", ".gitmodules", "submodule.lib.url", "../sub.git")
+	runGit(t, superDir, "commit", "-q", "-am", "add submodule")
+
+	pinned := runGit(t, subDir, "rev-parse", "HEAD")
+	superHead := runGit(t, superDir, "rev-parse", "HEAD")
+
+	// A newer commit pushed in the checked out submodule, which the superproject does not pin yet
+	libDir := filepath.Join(superDir, "lib")
+	os.WriteFile(filepath.Join(libDir, "README.md"), []byte("line1\nline2\nline3\nline4\n"), 0666)
+	runGit(t, libDir, "commit", "-q", "-am", "newer")
+	newer := runGit(t, libDir, "rev-parse", "HEAD")
+	runGit(t, libDir, "update-ref", "refs/remotes/origin/master", newer)
+
+	// A clone without initialized submodules
+	cloneDir := filepath.Join(testDir, "clone")
+	runGit(t, testDir, "clone", "-q", superDir, cloneDir)
+	runGit(t, cloneDir, "remote", "set-url", "origin", "https://github.com/inouet/super.git")
+
+	// A clone whose submodule url can not be linked
+	localDir := filepath.Join(testDir, "local")
+	runGit(t, testDir, "clone", "-q", superDir, localDir)
+	runGit(t, localDir, "remote", "set-url", "origin", "https://github.com/inouet/super.git")
+	runGit(t, localDir, "config", "-f", ".gitmodules", "submodule.lib.url", subDir)
+
+	cases := map[string]struct {
+		path  string
+		line1 int
+		want  string
+	}{
+		"file-in-submodule": {
+			path:  filepath.Join(superDir, "lib", "README.md"),
+			line1: 4,
+			want:  "https://github.com/inouet/sub/tree/" + newer + "/README.md#L4",
+		},
+		"submodule-dir": {
+			path: filepath.Join(superDir, "lib"),
+			want: "https://github.com/inouet/sub/tree/" + pinned + "/",
+		},
+		"uninitialized-submodule": {
+			path: filepath.Join(cloneDir, "lib"),
+			want: "https://github.com/inouet/sub/tree/" + pinned + "/",
+		},
+		"gitlink-fallback": {
+			path: filepath.Join(localDir, "lib"),
+			want: "https://github.com/inouet/super/tree/" + superHead + "/lib",
+		},
+		"gitlink-fallback-lines": {
+			path:  filepath.Join(localDir, "lib"),
+			line1: 2,
+			want:  "https://github.com/inouet/super/tree/" + superHead + "/lib",
+		},
+		"superproject-file": {
+			path: filepath.Join(superDir, "README.md"),
+			want: "https://github.com/inouet/super/tree/" + superHead + "/README.md",
+		},
+	}
+
+	for name, c := range cases {
+		gr, err := newGitRemote(context.Background(), nil, c.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := gr.remoteURL("", testLines(c.line1, 0))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if got != c.want {
+			t.Errorf("%s want '%s', got '%s'\n", name, c.want, got)
+		}
+	}
+}
+
+func TestResolveSubmoduleURL(t *testing.T) {
+	cases := []struct {
+		base string
+		url  string
+		want string
+	}{
+		{base: "git@github.com:inouet/super.git", url: "../lib.git", want: "git@github.com:inouet/lib.git"},
+		{base: "https://github.com/inouet/super.git", url: "../lib.git", want: "https://github.com/inouet/lib.git"},
+		{base: "https://github.com/inouet/super", url: "./lib", want: "https://github.com/inouet/super/lib"},
+		{base: "https://github.com/inouet/super.git", url: "https://gitlab.com/foo/lib.git", want: "https://gitlab.com/foo/lib.git"},
+	}
+	for _, c := range cases {
+		got := resolveSubmoduleURL(c.base, c.url)
+		if got != c.want {
+			t.Errorf("want '%s', got '%s'\n", c.want, got)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package ghopen
+
+import (
+	"context"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const gitConfigRootName string = "gh-open.root"
+
+// maxCloneDepth is the depth of the directories searched for the clones under a root,
+// eg: <root>/<host>/<group>/<subgroup>/<repo> of ghq
+const maxCloneDepth = 5
+
+// LocalFile is the file of a web URL in a local clone
+type LocalFile struct {
+	Dir   string      // Top directory of the clone
+	Path  string      // Absolute path of the file (or the directory)
+	Ref   string      // Ref of the URL, which may differ from the checkout
+	Lines []LineRange // Lines of the URL, translated to the working file
+}
+
+// FindLocal finds the file of the web URL (eg: a link pasted in chat) in the clones under the roots.
+// Nothing is checked out, the lines at the ref of the URL are translated to the working file.
+func (r Resolver) FindLocal(ctx context.Context, rawURL string, roots []string) (LocalFile, error) {
+	if r.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.Timeout)
+		defer cancel()
+	}
+	s, err := parseServiceURL(rawURL, "")
+	if err != nil {
+		return LocalFile{}, err
+	}
+	dir := findClone(s.base, roots)
+	if dir == "" {
+		return LocalFile{}, errorf(ErrNotExist, "no clone of %s%s in %s, try git config --global %s <dir>",
+			s.base.Host, s.base.Path, strings.Join(roots, ", "), gitConfigRootName)
+	}
+
+	b, err := newBackend(r.Backend, r.Runner)
+	if err != nil {
+		return LocalFile{}, err
+	}
+	git, err := newGit(ctx, b, dir)
+	if err != nil {
+		return LocalFile{}, err
+	}
+	if s.refPath != "" {
+		s.splitRefPath(func(name string) bool {
+			return git.hasRef("refs/heads/"+name) || git.hasRef("refs/remotes/"+remoteName+"/"+name) || git.hasRef("refs/tags/"+name)
+		})
+	}
+
+	file := LocalFile{
+		Dir:   git.dir,
+		Path:  filepath.Join(git.dir, filepath.FromSlash(s.path)),
+		Ref:   s.ref.name,
+		Lines: s.lines,
+	}
+	if !isFile(file.Path) && !isDir(file.Path) {
+		return LocalFile{}, errorf(ErrNotExist, "%s does not exist in the work tree of %s (the url is at %s)", s.path, git.dir, s.ref.name)
+	}
+	gr := gitRemote{git: git, path: filepath.FromSlash(s.path), warn: r.Warn}
+	file.Lines = gr.mapLinesToWorkTree(s.ref.name, s.lines)
+	return file, nil
+}
+
+// FindLocal finds the file of the web URL by a Resolver dropping the warnings
+func FindLocal(ctx context.Context, rawURL string, roots []string) (LocalFile, error) {
+	return Resolver{}.FindLocal(ctx, rawURL, roots)
+}
+
+// SearchRoots returns the existing directories to search the clones in:
+// git config gh-open.root (a list of paths separated as $PATH), the root of ghq
+// ($GHQ_ROOT, git config ghq.root or ~/ghq) and ~/src
+func SearchRoots(ctx context.Context) []string {
+	home, _ := os.UserHomeDir()
+	var roots []string
+	if git, err := newGit(ctx, nil, existingDir(home)); err == nil {
+		roots = append(roots, filepath.SplitList(git.getConfig(gitConfigRootName, ""))...)
+		if ghqRoot := os.Getenv("GHQ_ROOT"); ghqRoot != "" {
+			roots = append(roots, filepath.SplitList(ghqRoot)...)
+		} else {
+			roots = append(roots, git.getConfig("ghq.root", filepath.Join(home, "ghq")))
+		}
+	}
+	roots = append(roots, filepath.Join(home, "src"))
+
+	var dirs []string
+	seen := map[string]bool{}
+	for _, root := range roots {
+		if rest, found := strings.CutPrefix(root, "~"); found && home != "" {
+			root = home + rest
+		}
+		if root == "" || seen[root] || !isDir(root) {
+			continue
+		}
+		seen[root] = true
+		dirs = append(dirs, root)
+	}
+	return dirs
+}
+
+// findClone returns the top directory of the clone of the repository under the roots,
+// at <root>/<host>/<path> as ghq clones, or anywhere up to maxCloneDepth
+func findClone(base url.URL, roots []string) string {
+	for _, root := range roots {
+		dir := filepath.Join(root, base.Host, filepath.FromSlash(base.Path))
+		if isCloneOf(dir, base) {
+			return dir
+		}
+	}
+	for _, root := range roots {
+		found := ""
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || !d.IsDir() {
+				return nil
+			}
+			if path != root && strings.HasPrefix(d.Name(), ".") {
+				return fs.SkipDir
+			}
+			if isCloneOf(path, base) {
+				found = path
+				return fs.SkipAll
+			}
+			rel, _ := filepath.Rel(root, path)
+			if _, ok := readGitDir(filepath.Join(path, ".git")); ok || strings.Count(rel, string(filepath.Separator)) >= maxCloneDepth-1 {
+				// Another repository, the submodules are not searched
+				return fs.SkipDir
+			}
+			return nil
+		})
+		if found != "" {
+			return found
+		}
+	}
+	return ""
+}
+
+// isCloneOf reports whether dir is a work tree whose origin is the repository
+func isCloneOf(dir string, base url.URL) bool {
+	gitDir, ok := readGitDir(filepath.Join(dir, ".git"))
+	if !ok {
+		return false
+	}
+	values, err := parseConfigFile(filepath.Join(commonDir(gitDir), "config"))
+	if err != nil {
+		return false
+	}
+	remote, err := parseRemoteURL(values["remote."+remoteName+".url"], base.Scheme)
+	return err == nil && strings.EqualFold(remote.Host, base.Host) &&
+		strings.EqualFold(strings.TrimSuffix(remote.Path, ".git"), base.Path)
+}
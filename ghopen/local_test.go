@@ -0,0 +1,112 @@
+package ghopen
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFindLocal(t *testing.T) {
+	testDir := mkTempDir()
+	defer os.RemoveAll(testDir)
+
+	// A ghq root, and a root with another layout
+	ghqRoot := filepath.Join(testDir, "ghq")
+	ghqDir := filepath.Join(ghqRoot, "github.com", "inouet", "gh-open")
+	mkGitRepo(t, ghqDir, "git@github.com:inouet/gh-open.git")
+	runGit(t, ghqDir, "branch", "feature/login")
+	pushed := runGit(t, ghqDir, "rev-parse", "HEAD")
+	// Two lines are added before line 2 in the working file
+	os.WriteFile(filepath.Join(ghqDir, "README.md"), []byte("line1\nnew1\nnew2\nline2\nline3\n"), 0666)
+
+	srcRoot := filepath.Join(testDir, "src")
+	srcDir := filepath.Join(srcRoot, "work", "docker")
+	mkGitRepo(t, srcDir, "https://gitlab.com/gitlab-examples/docker.git")
+	mkGitRepo(t, filepath.Join(srcRoot, "other"), "https://gitlab.com/gitlab-examples/other.git")
+
+	roots := []string{ghqRoot, srcRoot}
+	cases := map[string]struct {
+		url         string
+		want        LocalFile
+		wantErr     error
+		wantWarning string
+	}{
+		"ghq": {
+			url:  "https://github.com/inouet/gh-open/blob/" + pushed + "/README.md#L2-L3",
+			want: LocalFile{Dir: ghqDir, Path: filepath.Join(ghqDir, "README.md"), Ref: pushed, Lines: []LineRange{{Start: 4, End: 5}}},
+		},
+		"branch-with-slash": {
+			url:  "https://github.com/Inouet/gh-open/tree/feature/login/README.md",
+			want: LocalFile{Dir: ghqDir, Path: filepath.Join(ghqDir, "README.md"), Ref: "feature/login"},
+		},
+		"search": {
+			url:  "https://gitlab.com/gitlab-examples/docker/-/blob/master/README.md#L1-2",
+			want: LocalFile{Dir: srcDir, Path: filepath.Join(srcDir, "README.md"), Ref: "master", Lines: []LineRange{{Start: 1, End: 2}}},
+		},
+		"unfetched-ref": {
+			url:         "https://gitlab.com/gitlab-examples/docker/-/blob/release/README.md#L1",
+			want:        LocalFile{Dir: srcDir, Path: filepath.Join(srcDir, "README.md"), Ref: "release", Lines: []LineRange{{Start: 1}}},
+			wantWarning: "line numbers are not translated",
+		},
+		"no-clone": {
+			url:     "https://github.com/inouet/other/blob/main/README.md",
+			wantErr: ErrNotExist,
+		},
+		"no-file": {
+			url:     "https://github.com/inouet/gh-open/blob/master/main.go",
+			wantErr: ErrNotExist,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			var warnings []string
+			resolver := Resolver{Warn: func(msg string) { warnings = append(warnings, msg) }}
+			got, err := resolver.FindLocal(context.Background(), c.url, roots)
+			if c.wantErr != nil {
+				if !errors.Is(err, c.wantErr) {
+					t.Errorf("want %v, got %v\n", c.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("want %+v, got %+v\n", c.want, got)
+			}
+			if c.wantWarning != "" && (len(warnings) != 1 || !strings.Contains(warnings[0], c.wantWarning)) {
+				t.Errorf("want the warning '%s', got %v\n", c.wantWarning, warnings)
+			}
+		})
+	}
+}
+
+func TestSearchRoots(t *testing.T) {
+	home := mkTempDir()
+	defer os.RemoveAll(home)
+
+	for _, dir := range []string{"src", "ghq", "code", "work"} {
+		os.MkdirAll(filepath.Join(home, dir), 0777)
+	}
+	t.Setenv("HOME", home)
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(home, ".gitconfig"))
+	t.Setenv("GHQ_ROOT", "")
+	runGit(t, home, "config", "--global", gitConfigRootName, "~/code"+string(filepath.ListSeparator)+"~/missing")
+
+	want := []string{filepath.Join(home, "code"), filepath.Join(home, "ghq"), filepath.Join(home, "src")}
+	got := SearchRoots(context.Background())
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v\n", want, got)
+	}
+
+	t.Setenv("GHQ_ROOT", filepath.Join(home, "work"))
+	want = []string{filepath.Join(home, "code"), filepath.Join(home, "work"), filepath.Join(home, "src")}
+	got = SearchRoots(context.Background())
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v\n", want, got)
+	}
+}
@@ -0,0 +1,136 @@
+package ghopen
+
+import (
+	"bytes"
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// locateLines finds the lines by --match or --symbol in the file at ref (localRef in the local repository)
+func (r gitRemote) locateLines(ref, localRef, refPath string) ([]LineRange, error) {
+	content, err := r.contentAt(ref, localRef, refPath)
+	if err != nil {
+		return nil, err
+	}
+	if r.symbol != "" {
+		if filepath.Ext(refPath) != ".go" {
+			return nil, errorf(ErrInvalidLine, "--symbol supports only Go files: %s", refPath)
+		}
+		return findSymbol(content, r.symbol)
+	}
+	return findMatch(content, r.match)
+}
+
+// contentAt returns the contents of the file at ref,
+// or the working file when ref is not available locally.
+// The go backend, which does not read the objects, always searches the working file.
+func (r gitRemote) contentAt(ref, localRef, refPath string) ([]byte, error) {
+	if localRef != "" {
+		content, err := r.git.getFileContent(localRef, filepath.ToSlash(refPath))
+		if !errors.Is(err, errNoObjects) || r.git.bare {
+			return content, err
+		}
+		return os.ReadFile(filepath.Join(r.git.dir, r.path))
+	}
+	if r.git.bare {
+		return nil, errorf(ErrUnknownRevision, "unknown revision: %s", ref)
+	}
+	r.warnf("%s is not available locally, searching the working file", ref)
+	return os.ReadFile(filepath.Join(r.git.dir, r.path))
+}
+
+// findMatch returns the lines of the first match of the regular expression
+func findMatch(content []byte, pattern string) ([]LineRange, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, errorf(ErrInvalidLine, "invalid --match pattern: %v", err)
+	}
+	loc := re.FindIndex(content)
+	if loc == nil {
+		return nil, errorf(ErrNoMatch, "no match for '%s'", pattern)
+	}
+	start := lineAt(content, loc[0])
+	end := lineAt(content, max(loc[1]-1, loc[0]))
+	if end == start {
+		end = 0
+	}
+	return []LineRange{{Start: start, End: end}}, nil
+}
+
+// lineAt returns the line number of the byte offset
+func lineAt(content []byte, offset int) int {
+	return bytes.Count(content[:offset], []byte("\n")) + 1
+}
+
+// findSymbol returns the lines of the declaration of a function, method (Type.Method) or type
+func findSymbol(content []byte, symbol string) ([]LineRange, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", content, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	var node ast.Node
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if funcName(d) == symbol {
+				node = d
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				ts, ok := spec.(*ast.TypeSpec)
+				if !ok || ts.Name.Name != symbol {
+					continue
+				}
+				node = ts
+				if !d.Lparen.IsValid() {
+					// type Foo struct{...} rather than type ( Foo struct{...} )
+					node = d
+				}
+			}
+		}
+		if node != nil {
+			break
+		}
+	}
+	if node == nil {
+		return nil, errorf(ErrNoMatch, "symbol not found: %s", symbol)
+	}
+
+	start := fset.Position(node.Pos()).Line
+	end := fset.Position(node.End()).Line
+	if end == start {
+		end = 0
+	}
+	return []LineRange{{Start: start, End: end}}, nil
+}
+
+// funcName returns the name of the function, or Type.Method for methods
+func funcName(d *ast.FuncDecl) string {
+	if d.Recv == nil || len(d.Recv.List) == 0 {
+		return d.Name.Name
+	}
+	recv := d.Recv.List[0].Type
+	for {
+		switch t := recv.(type) {
+		case *ast.StarExpr:
+			recv = t.X
+			continue
+		case *ast.IndexExpr:
+			recv = t.X
+			continue
+		case *ast.IndexListExpr:
+			recv = t.X
+			continue
+		case *ast.Ident:
+			return t.Name + "." + d.Name.Name
+		}
+		return d.Name.Name
+	}
+}
@@ -0,0 +1,121 @@
+package ghopen
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const locateSource = `package main
+
+// Foo is a type
+type Foo struct {
+	bar int
+}
+
+type (
+	Baz  int
+	List[T any] []T
+)
+
+func main() {
+	println("hello")
+}
+
+func (f *Foo) Bar() int {
+	return f.bar
+}
+
+func (l List[T]) Len() int { return len(l) }
+`
+
+func TestFindSymbol(t *testing.T) {
+	cases := []struct {
+		symbol    string
+		wantLines []LineRange
+		wantErr   bool
+	}{
+		{symbol: "Foo", wantLines: []LineRange{{Start: 4, End: 6}}},
+		{symbol: "Baz", wantLines: []LineRange{{Start: 9}}},
+		{symbol: "main", wantLines: []LineRange{{Start: 13, End: 15}}},
+		{symbol: "Foo.Bar", wantLines: []LineRange{{Start: 17, End: 19}}},
+		{symbol: "List.Len", wantLines: []LineRange{{Start: 21}}},
+		{symbol: "Bar", wantErr: true},
+	}
+	for _, c := range cases {
+		got, err := findSymbol([]byte(locateSource), c.symbol)
+		if c.wantErr != (err != nil) {
+			t.Errorf("'%s' wantErr %v, got %v\n", c.symbol, c.wantErr, err)
+		}
+		if !reflect.DeepEqual(got, c.wantLines) {
+			t.Errorf("'%s' want %v, got %v\n", c.symbol, c.wantLines, got)
+		}
+	}
+}
+
+func TestFindMatch(t *testing.T) {
+	cases := []struct {
+		pattern   string
+		wantLines []LineRange
+		wantErr   bool
+	}{
+		{pattern: `func main`, wantLines: []LineRange{{Start: 13}}},
+		{pattern: `(?s)func main.*?\n}`, wantLines: []LineRange{{Start: 13, End: 15}}},
+		{pattern: `not found`, wantErr: true},
+		{pattern: `(`, wantErr: true},
+	}
+	for _, c := range cases {
+		got, err := findMatch([]byte(locateSource), c.pattern)
+		if c.wantErr != (err != nil) {
+			t.Errorf("'%s' wantErr %v, got %v\n", c.pattern, c.wantErr, err)
+		}
+		if !reflect.DeepEqual(got, c.wantLines) {
+			t.Errorf("'%s' want %v, got %v\n", c.pattern, c.wantLines, got)
+		}
+	}
+}
+
+func TestRemoteUrlSymbol(t *testing.T) {
+	testDir := mkTempDir()
+	defer os.RemoveAll(testDir)
+
+	mkGitRepo(t, testDir, "https://github.com/inouet/gh-open.git")
+	os.WriteFile(filepath.Join(testDir, "main.go"), []byte(locateSource), 0666)
+	runGit(t, testDir, "add", "main.go")
+	runGit(t, testDir, "commit", "-q", "-m", "add main.go")
+	head := runGit(t, testDir, "rev-parse", "HEAD")
+
+	// The working file has changed, the lines are found at the commit
+	os.WriteFile(filepath.Join(testDir, "main.go"), []byte("// new line\n"+locateSource), 0666)
+
+	gr, err := newGitRemote(context.Background(), nil, filepath.Join(testDir, "main.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gr.symbol = "Foo.Bar"
+	want := "https://github.com/inouet/gh-open/tree/" + head + "/main.go#L17-L19"
+	got, err := gr.remoteURL("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("want '%s', got '%s'\n", want, got)
+	}
+
+	// The repository root and the directories have no lines
+	os.MkdirAll(filepath.Join(testDir, "docs"), 0777)
+	for _, dir := range []string{testDir, filepath.Join(testDir, "docs")} {
+		gr, err := newGitRemote(context.Background(), nil, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gr.match = "func"
+		_, err = gr.remoteURL("", nil)
+		if !errors.Is(err, ErrInvalidOption) {
+			t.Errorf("%s want ErrInvalidOption, got %v\n", dir, err)
+		}
+	}
+}
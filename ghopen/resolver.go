@@ -0,0 +1,103 @@
+// Package ghopen resolves local files of git repositories to their web URLs
+// on GitHub, GitLab, Bitbucket, Gitea, Azure DevOps and googlesource.
+//
+//	loc, err := ghopen.Resolve(ctx, "main.go", ghopen.Options{Lines: lines})
+//	fmt.Println(loc.URL)
+package ghopen
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+)
+
+// Location is the resolved link of a path
+type Location struct {
+	Host    string      `json:"host"`
+	Owner   string      `json:"owner"`
+	Repo    string      `json:"repo"`
+	Ref     string      `json:"ref,omitempty"`
+	RefKind string      `json:"ref_kind,omitempty"` // branch, tag or commit
+	Path    string      `json:"path"`
+	Lines   []LineRange `json:"lines,omitempty"`
+	URL     string      `json:"url"`
+}
+
+// Options selects the revision and the lines to link to.
+// Without any revision option, the link mode of git config gh-open.linkmode is used.
+type Options struct {
+	Branch        string      // Branch name
+	Ref           string      // Any revision to link to instead of HEAD (eg: v1.0, HEAD~2)
+	Permalink     bool        // Always link to the full commit hash
+	DefaultBranch bool        // Link to the default branch of the remote
+	CurrentBranch bool        // Link to the upstream branch of the current branch
+	Tag           string      // Link to the tag of the commit, TagExact or TagNearest
+	Lines         []LineRange // Lines in the working file, translated to the linked revision
+	Match         string      // Select the lines of the first match of the regular expression
+	Symbol        string      // Select the lines of the declaration of a Go function, method (Type.Method) or type
+	RequirePushed bool        // Fail when HEAD is not pushed instead of linking to the merge-base with the upstream
+}
+
+// Resolver resolves paths to locations
+type Resolver struct {
+	// Warn receives the warnings (eg: HEAD is not pushed), which are dropped when nil
+	Warn func(msg string)
+	// Timeout limits the whole resolution, no limit when 0. Each git command times out after 30 seconds.
+	Timeout time.Duration
+	// Backend reads the repository, BackendAuto when empty
+	Backend string
+	// Runner runs the git commands of BackendExec and BackendAuto, the git command when nil
+	Runner Runner
+}
+
+// Resolve resolves the path (a file or a directory of a work tree, or a path in a bare repository)
+// to its location on the remote of the repository.
+func (r Resolver) Resolve(ctx context.Context, path string, opts Options) (Location, error) {
+	if r.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.Timeout)
+		defer cancel()
+	}
+	b, err := newBackend(r.Backend, r.Runner)
+	if err != nil {
+		return Location{}, err
+	}
+	switch opts.Tag {
+	case "", TagExact, TagNearest:
+	default:
+		return Location{}, errorf(ErrInvalidOption, "unknown tag: %s (valid: %s, %s)", opts.Tag, TagExact, TagNearest)
+	}
+	gr, err := newGitRemote(ctx, b, path)
+	if err != nil {
+		return Location{}, err
+	}
+	gr.ref = opts.Ref
+	gr.permalink = opts.Permalink
+	gr.defaultBranch = opts.DefaultBranch
+	gr.currentBranch = opts.CurrentBranch
+	gr.tag = opts.Tag
+	gr.match = opts.Match
+	gr.symbol = opts.Symbol
+	gr.requirePushed = opts.RequirePushed
+	gr.warn = r.Warn
+
+	return gr.resolve(opts.Branch, opts.Lines)
+}
+
+// Resolve resolves the path by a Resolver dropping the warnings
+func Resolve(ctx context.Context, path string, opts Options) (Location, error) {
+	return Resolver{}.Resolve(ctx, path, opts)
+}
+
+// Config returns the git config value of the repository of the path, or the default value
+func Config(ctx context.Context, path, name, defaultValue string) string {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return defaultValue
+	}
+	git, err := newGit(ctx, nil, existingDir(absPath))
+	if err != nil {
+		return defaultValue
+	}
+	return git.getConfig(name, defaultValue)
+}
@@ -0,0 +1,80 @@
+package ghopen
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// gitProcessCases are the resolutions measured by the number of git processes.
+//
+// The discovery of the repository, the config and HEAD took 7 processes at the baseline,
+// and 16 (commit) and 13 (branch) with the submodules, the worktrees and the remote checks,
+// before they were batched into one rev-parse and one config --get-regexp: 8 and 7.
+// The ref in the local repository is looked up once (none for a commit hash), since then:
+//   commit: rev-parse + config, for-each-ref x2 (HEAD is pushed), ls-tree (the path exists at the ref),
+//           diff (the lines are translated) => 6
+//   branch: rev-parse + config, show-ref x2 (the branch exists on origin), show-ref (the local ref), ls-tree, diff => 7
+var gitProcessCases = map[string]struct {
+	opts Options
+	want int
+}{
+	"commit": {opts: Options{Lines: testLines(2, 3)}, want: 6},
+	"branch": {opts: Options{Branch: "master", Lines: testLines(2, 3)}, want: 7},
+}
+
+// mkBenchRepo creates a repository whose HEAD is pushed to origin/master, and returns the path of README.md
+func mkBenchRepo(tb testing.TB, dir string) string {
+	mkGitRepo(tb, dir, "https://github.com/inouet/gh-open.git")
+	head := runGit(tb, dir, "rev-parse", "HEAD")
+	runGit(tb, dir, "update-ref", "refs/remotes/origin/master", head)
+	return filepath.Join(dir, "README.md")
+}
+
+// countRunner counts the git commands
+type countRunner struct {
+	count *int
+}
+
+func (r countRunner) Run(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	*r.count++
+	return commandRunner{}.Run(ctx, dir, args...)
+}
+
+func TestResolveGitProcesses(t *testing.T) {
+	testDir := mkTempDir()
+	defer os.RemoveAll(testDir)
+	path := mkBenchRepo(t, testDir)
+
+	for name, c := range gitProcessCases {
+		count := 0
+		_, err := Resolver{Runner: countRunner{&count}}.Resolve(context.Background(), path, c.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != c.want {
+			t.Errorf("%s want %d git processes, got %d\n", name, c.want, count)
+		}
+	}
+}
+
+// BenchmarkResolve reports the number of git processes per resolution as git/op
+func BenchmarkResolve(b *testing.B) {
+	testDir := mkTempDir()
+	defer os.RemoveAll(testDir)
+	path := mkBenchRepo(b, testDir)
+
+	for name, c := range gitProcessCases {
+		b.Run(name, func(b *testing.B) {
+			start := gitProcesses.Load()
+			for i := 0; i < b.N; i++ {
+				_, err := Resolve(context.Background(), path, c.opts)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(gitProcesses.Load()-start)/float64(b.N), "git/op")
+		})
+	}
+}
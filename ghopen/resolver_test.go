@@ -0,0 +1,118 @@
+package ghopen
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	testDir := mkTempDir()
+	defer os.RemoveAll(testDir)
+
+	mkGitRepo(t, testDir, "git@github.com:inouet/gh-open.git")
+	pushed := runGit(t, testDir, "rev-parse", "HEAD")
+	runGit(t, testDir, "update-ref", "refs/remotes/origin/master", pushed)
+	runGit(t, testDir, "branch", "-q", "--set-upstream-to=origin/master")
+	runGit(t, testDir, "commit", "-q", "--allow-empty", "-m", "local")
+
+	var warnings []string
+	resolver := Resolver{Warn: func(msg string) { warnings = append(warnings, msg) }}
+	lines, err := ParseLines("2-3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	loc, err := resolver.Resolve(context.Background(), filepath.Join(testDir, "README.md"), Options{Lines: lines})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Location{
+		Host:    "github.com",
+		Owner:   "inouet",
+		Repo:    "gh-open",
+		Ref:     pushed,
+		RefKind: "commit",
+		Path:    "README.md",
+		Lines:   []LineRange{{Start: 2, End: 3}},
+		URL:     "https://github.com/inouet/gh-open/tree/" + pushed + "/README.md#L2-L3",
+	}
+	if !reflect.DeepEqual(loc, want) {
+		t.Errorf("want %+v, got %+v\n", want, loc)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "is not pushed to origin") {
+		t.Errorf("want the warning of the unpushed HEAD, got %v\n", warnings)
+	}
+
+	loc, err = Resolve(context.Background(), filepath.Join(testDir, "README.md"), Options{Branch: "master"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loc.Ref != "master" || loc.RefKind != "branch" {
+		t.Errorf("want master branch, got %s %s\n", loc.Ref, loc.RefKind)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Resolve(ctx, filepath.Join(testDir, "README.md"), Options{})
+	if err == nil {
+		t.Errorf("want the error of the canceled context\n")
+	}
+}
+
+func TestConfigValue(t *testing.T) {
+	testDir := mkTempDir()
+	defer os.RemoveAll(testDir)
+
+	mkGitRepo(t, testDir, "")
+	runGit(t, testDir, "config", "gh-open.browser", "firefox")
+
+	got := Config(context.Background(), filepath.Join(testDir, "README.md"), "gh-open.browser", "")
+	if got != "firefox" {
+		t.Errorf("want 'firefox', got '%s'\n", got)
+	}
+	got = Config(context.Background(), filepath.Join(testDir, "README.md"), "gh-open.urltype", "github.com")
+	if got != "github.com" {
+		t.Errorf("want the default value, got '%s'\n", got)
+	}
+}
+
+func TestResolverRunner(t *testing.T) {
+	testDir := mkTempDir()
+	defer os.RemoveAll(testDir)
+
+	head := "695895662d96bac8d94fd71dc9d2dec534c8e494"
+	os.WriteFile(filepath.Join(testDir, "README.md"), []byte("line1\nline2\nline3\n"), 0666)
+
+	for name, c := range remoteCases {
+		t.Run(name, func(t *testing.T) {
+			config := "remote.origin.url\n" + c.origin + "\x00"
+			for key, value := range c.config {
+				config += key + "\n" + value + "\x00"
+			}
+			runner := &fakeRunner{outputs: map[string]string{
+				"rev-parse --is-inside-work-tree --is-bare-repository --absolute-git-dir HEAD --show-toplevel --show-superproject-working-tree": "true\nfalse\n" + filepath.Join(testDir, ".git") + "\n" + head + "\n" + testDir + "\n",
+				"config -z --get-regexp .":                                        config,
+				"show-ref --verify --quiet refs/heads/master":                     "",
+				"show-ref --verify --quiet refs/remotes/origin/master":            "",
+				"ls-tree origin/master -- README.md":                              "100644 blob 83db48f84ec878fbfb30b46d16630e944e34f205\tREADME.md\n",
+				"diff -U0 -M --no-color --no-ext-diff origin/master -- README.md": "",
+			}}
+			loc, err := Resolver{Backend: BackendExec, Runner: runner}.Resolve(context.Background(), filepath.Join(testDir, "README.md"), Options{Branch: c.branch, Lines: c.lines})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if loc.URL != c.want {
+				t.Errorf("want '%s', got '%s'\n", c.want, loc.URL)
+			}
+			// Every git command is answered, nothing ran outside of the runner
+			for _, call := range runner.calls {
+				if _, ok := runner.outputs[call]; !ok {
+					t.Errorf("unexpected git %s\n", call)
+				}
+			}
+		})
+	}
+}
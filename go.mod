@@ -5,6 +5,7 @@ go 1.21
 require (
 	github.com/gitsight/go-vcsurl v1.0.1
 	github.com/jessevdk/go-flags v1.5.0
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
 	github.com/skratchdot/open-golang v0.0.0-20200116055534-eef842397966
 )
 
@@ -1,77 +1,355 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/inouet/gh-open/ghopen"
 	flags "github.com/jessevdk/go-flags"
-	"github.com/skratchdot/open-golang/open"
 )
 
 type options struct {
-	Line     string `short:"l" long:"line" description:"Line number (eg: 10 or 10-20)"`
+	Line     string `short:"l" long:"line" description:"Line number (eg: 10, 10-20, 10:5-12:20 or 10-20,30)"`
 	Branch   string `short:"b" long:"branch" description:"Branch name"`
 	PrintURL bool   `short:"p" long:"print" description:"Print url"`
+	Copy     bool   `short:"c" long:"copy" description:"Copy the url (or the formatted output) to the clipboard instead of opening it"`
+	OSC8     bool   `long:"osc8" description:"Print the output as a clickable terminal hyperlink (OSC 8) instead of opening it"`
+	QR       bool   `long:"qr" description:"Print the url as a QR code instead of opening it"`
+	Browser  string `long:"browser" description:"Browser or opener command, %s is replaced by the url (default: gh-open.browser config, $BROWSER)"`
+	Format   string `short:"f" long:"format" default:"url" choice:"url" choice:"json" choice:"markdown" choice:"html" choice:"org" description:"Output format, other than url implies --print"`
+	Template string `long:"template" description:"Go text/template for the output (eg: '{{.Path}} {{.URL}}'), implies --print"`
+	Local    string `long:"local" value-name:"URL" description:"Find the file of the url in the local clones, and open it in $EDITOR (or print path:line with -p)"`
+
+	Ref           string `long:"ref" description:"Revision to link to (eg: v1.0, HEAD~2)"`
+	Permalink     bool   `long:"permalink" description:"Always link to the full commit hash"`
+	DefaultBranch bool   `long:"default-branch" description:"Link to the default branch of the remote"`
+	CurrentBranch bool   `long:"current-branch" description:"Link to the upstream branch of the current branch"`
+	Match         string `long:"match" description:"Select the lines of the first match of the regular expression"`
+	Symbol        string `long:"symbol" description:"Select the lines of the declaration of a Go function, method (Type.Method) or type"`
+	Tag           string `long:"tag" optional:"yes" optional-value:"exact" choice:"exact" choice:"nearest" description:"Link to the tag pointing at the commit, or the nearest annotated tag containing it"`
+	Stdin         bool   `long:"stdin" description:"Read newline or NUL separated paths from stdin"`
+	RequirePushed bool   `long:"require-pushed" description:"Fail when HEAD is not pushed instead of linking to the merge-base with the upstream"`
+
+	Timeout time.Duration `long:"timeout" description:"Timeout of the resolution of each path (eg: 10s), each git command times out after 30s"`
+	Backend string        `long:"backend" default:"auto" choice:"auto" choice:"exec" choice:"go" description:"Run the git command (exec) or read the git directory (go), auto uses go when git is not installed"`
 }
 
-var (
-	opts        options
-	statusOK    = 0
-	statusError = 1
+var opts options
+
+// Exit status
+const (
+	statusOK            = 0
+	statusError         = 1 // Other errors
+	statusUsage         = 2 // Invalid options or lines, no match of --match or --symbol
+	statusNotRepository = 3 // Not a git repository
+	statusNoRemote      = 4 // No remote origin
+	statusUnsupported   = 5 // Unsupported git service
+	statusNotFound      = 6 // The path or the revision does not exist, or HEAD is not pushed
+	statusGitFailed     = 7 // A git command failed
 )
 
+// usageError is an error of the command line options
+type usageError string
+
+func (e usageError) Error() string {
+	return string(e)
+}
+
+// exitStatus returns the exit status for the error
+func exitStatus(err error) int {
+	var (
+		usageErr usageError
+		flagsErr *flags.Error
+		gitErr   *ghopen.GitError
+	)
+	switch {
+	case err == nil:
+		return statusOK
+	case errors.As(err, &usageErr), errors.As(err, &flagsErr),
+		errors.Is(err, ghopen.ErrInvalidLine), errors.Is(err, ghopen.ErrNoMatch), errors.Is(err, ghopen.ErrInvalidOption):
+		return statusUsage
+	case errors.Is(err, ghopen.ErrNotRepository):
+		return statusNotRepository
+	case errors.Is(err, ghopen.ErrNoRemote):
+		return statusNoRemote
+	case errors.Is(err, ghopen.ErrUnsupportedHost):
+		return statusUnsupported
+	case errors.Is(err, ghopen.ErrNotExist), errors.Is(err, ghopen.ErrUnknownRevision), errors.Is(err, ghopen.ErrNotPushed):
+		return statusNotFound
+	case errors.As(err, &gitErr):
+		return statusGitFailed
+	}
+	return statusError
+}
+
 func printError(err error) {
-	fmt.Printf("Error: %+v\n", err)
+	fmt.Fprintf(os.Stderr, "Error: %+v\n", err)
+}
+
+func printWarning(format string, a ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Warning: "+format+"\n", a...)
 }
 
 func main() {
 	os.Exit(realMain())
 }
 
-func realMain() int {
+// newParser returns the parser of the options and the subcommands.
+// A path named as a subcommand is opened by ./convert or after --.
+func newParser() *flags.Parser {
 	parser := flags.NewParser(&opts, flags.Default)
+	parser.Usage = "[OPTIONS] PATH..."
+	parser.SubcommandsOptional = true
+	parser.AddCommand("convert", "Convert urls to another host",
+		"Convert the urls of files to the url format of another host (eg: a mirror), keeping the ref, the path and the lines.",
+		&convertOpts)
+	parser.AddCommand("serve", "Serve the resolver over HTTP",
+		"Serve /resolve?path=/abs/file&line=10 (the location as JSON) and /open?url=... (redirect to the local file in the editor) on localhost for the editor and browser integrations.",
+		&serveOpts)
+	return parser
+}
+
+func realMain() int {
+	parser := newParser()
 	args, err := parser.Parse()
 
 	if err != nil {
+		var flagsErr *flags.Error
+		if errors.As(err, &flagsErr) && flagsErr.Type == flags.ErrHelp {
+			// The help is printed by the parser
+			return statusOK
+		}
 		printError(err)
-		return statusError
+		return exitStatus(err)
+	}
+
+	if parser.Active != nil {
+		switch parser.Active.Name {
+		case "convert":
+			return convertURLs(args)
+		case "serve":
+			return serve()
+		}
+	}
+
+	if opts.Local != "" {
+		if len(args) > 0 || opts.Stdin {
+			printError(usageError("--local can not be used with paths"))
+			return statusUsage
+		}
+		err := openLocal(opts.Local)
+		if err != nil {
+			printError(err)
+		}
+		return exitStatus(err)
+	}
+
+	if opts.Stdin {
+		paths, err := readPaths(os.Stdin)
+		if err != nil {
+			printError(err)
+			return statusError
+		}
+		args = append(args, paths...)
 	}
 
 	if len(args) == 0 {
 		parser.WriteHelp(os.Stdout)
-		return statusError
+		return statusUsage
+	}
+
+	if countTrue(opts.Branch != "", opts.Ref != "", opts.DefaultBranch, opts.CurrentBranch) > 1 {
+		printError(usageError("-b, --ref, --default-branch and --current-branch can not be used together"))
+		return statusUsage
+	}
+
+	if opts.Tag != "" && opts.Permalink {
+		printError(usageError("--tag and --permalink can not be used together"))
+		return statusUsage
+	}
+
+	// Errors of each path are reported without aborting the others,
+	// and the exit status is the one of the first error
+	status := statusOK
+	var outputs []string
+	for _, arg := range args {
+		output, err := openPath(arg)
+		if err == nil && output != "" {
+			outputs = append(outputs, output)
+		}
+		if err != nil {
+			// The errors of the path itself (eg: no such file) start with the path without the lines
+			path, _ := parsePathArgument(arg)
+			if len(args) > 1 && !strings.HasPrefix(err.Error(), path+": ") {
+				err = fmt.Errorf("%s: %w", arg, err)
+			}
+			printError(err)
+			if status == statusOK {
+				status = exitStatus(err)
+			}
+		}
+	}
+	if opts.Copy && len(outputs) > 0 {
+		copyOutputs(outputs)
+	}
+	return status
+}
+
+// openPath opens (or prints) the url of a path argument, and returns the formatted output
+// when it is printed or copied
+func openPath(arg string) (string, error) {
+	objectPath, lineOption := parsePathArgument(arg)
+	if lineOption == "" {
+		lineOption = opts.Line
+	} else if opts.Line != "" {
+		return "", usageError("the line is given both by -l and the argument")
+	}
+
+	if countTrue(lineOption != "", opts.Match != "", opts.Symbol != "") > 1 {
+		return "", usageError("the line, --match and --symbol can not be used together")
 	}
 
-	objectPath := args[0]
+	lines, err := ghopen.ParseLines(lineOption)
+	if err != nil {
+		return "", err
+	}
 
-	line1, line2, err := getLineOption(opts.Line)
+	ctx := context.Background()
+	resolver := ghopen.Resolver{
+		Warn:    func(msg string) { printWarning("%s", msg) },
+		Timeout: opts.Timeout,
+		Backend: opts.Backend,
+	}
+	loc, err := resolver.Resolve(ctx, objectPath, ghopen.Options{
+		Branch:        opts.Branch,
+		Ref:           opts.Ref,
+		Permalink:     opts.Permalink,
+		DefaultBranch: opts.DefaultBranch,
+		CurrentBranch: opts.CurrentBranch,
+		Tag:           opts.Tag,
+		Lines:         lines,
+		Match:         opts.Match,
+		Symbol:        opts.Symbol,
+		RequirePushed: opts.RequirePushed,
+	})
 	if err != nil {
-		printError(fmt.Errorf("invalid line format"))
-		return statusError
+		return "", err
 	}
 
-	gr, err := newGitRemote(objectPath)
+	if opts.QR {
+		qr, err := qrText(loc.URL)
+		if err != nil {
+			return "", err
+		}
+		fmt.Print(qr)
+	}
 
+	if opts.PrintURL || opts.Format != formatURL || opts.Template != "" || opts.Copy || opts.OSC8 {
+		output, err := formatLocation(loc, opts.Format, opts.Template)
+		if err != nil {
+			return "", err
+		}
+		if opts.OSC8 {
+			fmt.Println(osc8Link(loc.URL, output))
+		} else if opts.PrintURL || !opts.Copy {
+			fmt.Println(output)
+		}
+		return output, nil
+	}
+	if opts.QR {
+		return "", nil
+	}
+
+	// --browser takes precedence, the config is read only without it
+	configBrowser := ""
+	if opts.Browser == "" {
+		configBrowser = ghopen.Config(ctx, objectPath, gitConfigBrowserName, "")
+	}
+	browsers := browserCommands(opts.Browser, configBrowser, os.Getenv)
+	err = openURL(loc.URL, browsers)
+	if errors.Is(err, errNoDisplay) {
+		// Print the url rather than failing in an SSH session
+		printWarning("could not open the browser: %v", err)
+		fmt.Println(loc.URL)
+		return "", nil
+	}
 	if err != nil {
-		printError(err)
-		return statusError
+		return "", fmt.Errorf("could not open the browser: %w", err)
 	}
+	return "", nil
+}
 
-	remoteURL, err := gr.remoteURL(opts.Branch, line1, line2)
+// openLocal opens the local file of the url in the editor, or prints it as path:line
+func openLocal(rawURL string) error {
+	ctx := context.Background()
+	resolver := ghopen.Resolver{
+		Warn:    func(msg string) { printWarning("%s", msg) },
+		Timeout: opts.Timeout,
+		Backend: opts.Backend,
+	}
+	file, err := resolver.FindLocal(ctx, rawURL, ghopen.SearchRoots(ctx))
 	if err != nil {
-		printError(err)
-		return statusError
+		return err
+	}
+	line := 0
+	if len(file.Lines) > 0 {
+		line = file.Lines[0].Start
 	}
 
-	if opts.PrintURL {
-		fmt.Println(remoteURL)
-		return statusOK
+	editor := editorCommand(os.Getenv)
+	if opts.PrintURL || editor == "" {
+		fmt.Println(fileLine(file.Path, line))
+		return nil
 	}
+	return openEditor(editor, file.Path, line)
+}
 
-	err = open.Run(remoteURL)
+// copyOutputs copies the outputs of all paths at once, printing them when there is no clipboard
+func copyOutputs(outputs []string) {
+	text := strings.Join(outputs, "\n")
+	err := copyToClipboard(text)
 	if err != nil {
-		printError(err)
-		return statusError
+		printWarning("could not copy to the clipboard: %v", err)
+		if !opts.PrintURL {
+			fmt.Println(text)
+		}
+	}
+}
+
+// readPaths reads newline or NUL separated paths (eg: git diff --name-only -z)
+func readPaths(r io.Reader) ([]string, error) {
+	input, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	sep := "\n"
+	if strings.Contains(string(input), "\x00") {
+		sep = "\x00"
+	}
+	var paths []string
+	for _, path := range strings.Split(string(input), sep) {
+		if sep == "\n" {
+			// CRLF, the names with spaces are kept as is
+			path = strings.TrimSuffix(path, "\r")
+		}
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+func countTrue(values ...bool) int {
+	count := 0
+	for _, v := range values {
+		if v {
+			count++
+		}
 	}
-	return statusOK
+	return count
 }
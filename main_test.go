@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/inouet/gh-open/ghopen"
+)
+
+// mkTempDir creates a temporary directory as the tests of ghopen do
+func mkTempDir() string {
+	tmpDir := os.TempDir()
+	testDir, err := os.MkdirTemp(tmpDir, "tests-*")
+	if err != nil {
+		log.Fatal(err)
+	}
+	return testDir
+}
+
+func TestReadPaths(t *testing.T) {
+	cases := []struct {
+		input string
+		want  []string
+	}{
+		{input: "", want: nil},
+		{input: "main.go\nREADME.md\n", want: []string{"main.go", "README.md"}},
+		{input: "main.go:10\r\n\r\nREADME.md\r\n", want: []string{"main.go:10", "README.md"}},
+		{input: "main.go\x00file with\nnewline.txt\x00", want: []string{"main.go", "file with\nnewline.txt"}},
+		{input: " leading.go\ntrailing.go \n", want: []string{" leading.go", "trailing.go "}},
+		{input: "main.go\x00 spaced \x00crlf\r\x00", want: []string{"main.go", " spaced ", "crlf\r"}},
+	}
+	for _, c := range cases {
+		got, err := readPaths(strings.NewReader(c.input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%q want %q, got %q\n", c.input, c.want, got)
+		}
+	}
+}
+
+func TestExitStatus(t *testing.T) {
+	cases := []struct {
+		err  error
+		want int
+	}{
+		{err: nil, want: statusOK},
+		{err: usageError("--tag and --permalink can not be used together"), want: statusUsage},
+		{err: ghopen.ErrInvalidLine, want: statusUsage},
+		{err: fmt.Errorf("main.go: %w", ghopen.ErrNotRepository), want: statusNotRepository},
+		{err: ghopen.ErrNoRemote, want: statusNoRemote},
+		{err: ghopen.ErrUnsupportedHost, want: statusUnsupported},
+		{err: ghopen.ErrNotPushed, want: statusNotFound},
+		{err: &ghopen.GitError{Args: []string{"status"}}, want: statusGitFailed},
+		{err: errors.New("unknown"), want: statusError},
+	}
+	for _, c := range cases {
+		got := exitStatus(c.err)
+		if got != c.want {
+			t.Errorf("%v want %d, got %d\n", c.err, c.want, got)
+		}
+	}
+}
+
+func TestParseSubcommands(t *testing.T) {
+	defer func(o options, co convertOptions) { opts, convertOpts = o, co }(opts, convertOpts)
+
+	cases := []struct {
+		args       []string
+		wantActive string
+		wantArgs   []string
+	}{
+		{args: []string{"main.go", "README.md"}, wantActive: "", wantArgs: []string{"main.go", "README.md"}},
+		{args: []string{"convert", "https://github.com/inouet/gh-open", "--to", "gitlab.com"}, wantActive: "convert", wantArgs: []string{"https://github.com/inouet/gh-open"}},
+		{args: []string{"serve"}, wantActive: "serve", wantArgs: []string{}},
+		// The paths named as the subcommands
+		{args: []string{"./convert"}, wantActive: "", wantArgs: []string{"./convert"}},
+		{args: []string{"--", "serve"}, wantActive: "", wantArgs: []string{"serve"}},
+		{args: []string{"-p", "--", "convert", "serve"}, wantActive: "", wantArgs: []string{"convert", "serve"}},
+	}
+	for _, c := range cases {
+		parser := newParser()
+		args, err := parser.ParseArgs(c.args)
+		if err != nil {
+			t.Fatalf("%v: %v", c.args, err)
+		}
+		active := ""
+		if parser.Active != nil {
+			active = parser.Active.Name
+		}
+		if active != c.wantActive || !reflect.DeepEqual(args, c.wantArgs) {
+			t.Errorf("%v want %s %v, got %s %v\n", c.args, c.wantActive, c.wantArgs, active, args)
+		}
+	}
+}
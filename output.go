@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"strings"
+	"text/template"
+
+	"github.com/inouet/gh-open/ghopen"
+)
+
+// Values of --format
+const (
+	formatURL      string = "url"
+	formatJSON     string = "json"
+	formatMarkdown string = "markdown"
+	formatHTML     string = "html"
+	formatOrg      string = "org"
+)
+
+// formatLocation formats the location by --format or --template
+func formatLocation(loc ghopen.Location, format, tmpl string) (string, error) {
+	if tmpl != "" {
+		t, err := template.New("gh-open").Parse(tmpl)
+		if err != nil {
+			return "", fmt.Errorf("invalid template: %v", err)
+		}
+		var buf bytes.Buffer
+		err = t.Execute(&buf, loc)
+		if err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+
+	switch format {
+	case "", formatURL:
+		return loc.URL, nil
+	case formatJSON:
+		out, err := json.Marshal(loc)
+		return string(out), err
+	case formatMarkdown:
+		return fmt.Sprintf("[%s](%s)", escapeBrackets(label(loc)), loc.URL), nil
+	case formatHTML:
+		return fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(loc.URL), html.EscapeString(label(loc))), nil
+	case formatOrg:
+		return fmt.Sprintf("[[%s][%s]]", loc.URL, replaceBrackets(label(loc))), nil
+	}
+	return "", fmt.Errorf("unknown format: %s", format)
+}
+
+// escapeBrackets escapes the brackets of the label, which would end the link of markdown
+func escapeBrackets(text string) string {
+	return strings.NewReplacer("[", `\[`, "]", `\]`).Replace(text)
+}
+
+// replaceBrackets replaces the brackets of the label by braces for org, which has no escapes
+// and ends the description at ]]
+func replaceBrackets(text string) string {
+	return strings.NewReplacer("[", "{", "]", "}").Replace(text)
+}
+
+// label returns the text of the link
+//   main.go#L10-L20, or owner/repo for the repository
+func label(loc ghopen.Location) string {
+	if loc.Path == "" {
+		return strings.TrimPrefix(loc.Owner+"/"+loc.Repo, "/")
+	}
+	var ranges []string
+	for _, l := range loc.Lines {
+		r := fmt.Sprintf("L%d", l.Start)
+		if l.End != 0 {
+			r += fmt.Sprintf("-L%d", l.End)
+		}
+		ranges = append(ranges, r)
+	}
+	if len(ranges) == 0 {
+		return loc.Path
+	}
+	return loc.Path + "#" + strings.Join(ranges, ",")
+}
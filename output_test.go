@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/inouet/gh-open/ghopen"
+)
+
+func TestFormatLocation(t *testing.T) {
+	loc := ghopen.Location{
+		Host:    "github.com",
+		Owner:   "inouet",
+		Repo:    "gh-open",
+		Ref:     "master",
+		RefKind: "branch",
+		Path:    "main.go",
+		Lines:   []ghopen.LineRange{{Start: 10, End: 20}},
+		URL:     "https://github.com/inouet/gh-open/tree/master/main.go#L10-L20",
+	}
+	root := ghopen.Location{Host: "github.com", Owner: "inouet", Repo: "gh-open", URL: "https://github.com/inouet/gh-open"}
+	bracket := ghopen.Location{Host: "github.com", Owner: "inouet", Repo: "gh-open", Ref: "master", Path: "docs/[draft].md", URL: "https://github.com/inouet/gh-open/tree/master/docs/%5Bdraft%5D.md"}
+
+	cases := []struct {
+		loc    ghopen.Location
+		format string
+		tmpl   string
+		want   string
+	}{
+		{loc: loc, format: formatURL, want: loc.URL},
+		{
+			loc:    loc,
+			format: formatJSON,
+			want:   `{"host":"github.com","owner":"inouet","repo":"gh-open","ref":"master","ref_kind":"branch","path":"main.go","lines":[{"start":10,"end":20}],"url":"https://github.com/inouet/gh-open/tree/master/main.go#L10-L20"}`,
+		},
+		{loc: loc, format: formatMarkdown, want: "[main.go#L10-L20](https://github.com/inouet/gh-open/tree/master/main.go#L10-L20)"},
+		{loc: loc, format: formatHTML, want: `<a href="https://github.com/inouet/gh-open/tree/master/main.go#L10-L20">main.go#L10-L20</a>`},
+		{loc: loc, format: formatOrg, want: "[[https://github.com/inouet/gh-open/tree/master/main.go#L10-L20][main.go#L10-L20]]"},
+		{loc: root, format: formatMarkdown, want: "[inouet/gh-open](https://github.com/inouet/gh-open)"},
+		{loc: bracket, format: formatMarkdown, want: `[docs/\[draft\].md](https://github.com/inouet/gh-open/tree/master/docs/%5Bdraft%5D.md)`},
+		{loc: bracket, format: formatOrg, want: `[[https://github.com/inouet/gh-open/tree/master/docs/%5Bdraft%5D.md][docs/{draft}.md]]`},
+		{loc: loc, tmpl: "{{.Owner}}/{{.Repo}}@{{.Ref}} {{.Path}}:{{range .Lines}}{{.}}{{end}}", want: "inouet/gh-open@master main.go:10-20"},
+	}
+	for _, c := range cases {
+		got, err := formatLocation(c.loc, c.format, c.tmpl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != c.want {
+			t.Errorf("want '%s', got '%s'\n", c.want, got)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/inouet/gh-open/ghopen"
+)
+
+// serveOptions are the options of gh-open serve
+type serveOptions struct {
+	Addr        string   `long:"addr" default:"127.0.0.1:7777" description:"Address to listen on, a loopback one unless --allow-remote"`
+	AllowRemote bool     `long:"allow-remote" description:"Allow listening on an address other than loopback, which exposes the files of the repositories to the network"`
+	AllowOrigin []string `long:"allow-origin" description:"Origin allowed to make the requests (eg: chrome-extension://<id>), can be repeated"`
+	EditorURL   string   `long:"editor-url" default:"vscode://file{path}:{line}" description:"URL of the editor which /open redirects to, {path} and {line} are replaced (eg: idea://open?file={path}&line={line})"`
+}
+
+var serveOpts serveOptions
+
+// resolveResponse is the response of /resolve
+type resolveResponse struct {
+	ghopen.Location
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// errorResponse is the response of the errors
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// serve serves /resolve and /open for the editors and the browser extensions
+func serve() int {
+	if !serveOpts.AllowRemote && !isLoopbackAddr(serveOpts.Addr) {
+		err := usageError(fmt.Sprintf("%s is not a loopback address, use --allow-remote to listen on it", serveOpts.Addr))
+		printError(err)
+		return exitStatus(err)
+	}
+	server := &http.Server{
+		Addr:              serveOpts.Addr,
+		Handler:           newServeHandler(serveOpts.EditorURL, serveOpts.AllowOrigin),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	fmt.Fprintf(os.Stderr, "Listening on http://%s\n", serveOpts.Addr)
+	err := server.ListenAndServe()
+	printError(err)
+	return statusError
+}
+
+// newServeHandler returns the handler of the endpoints
+//   GET /resolve?path=/abs/file&line=10 => the location as JSON
+//   GET /open?url=https://github.com/... => redirect to the local file in the editor
+func newServeHandler(editorURL string, origins []string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/resolve", handleResolve)
+	mux.HandleFunc("/open", func(w http.ResponseWriter, r *http.Request) {
+		handleOpen(w, r, editorURL)
+	})
+	return localOnly(mux, origins)
+}
+
+// localOnly rejects the requests other than GET, the requests by another host name than a loopback one,
+// which a web page can make by DNS rebinding, and the cross-site requests other than from the origins
+func localOnly(next http.Handler, origins []string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeJSON(w, http.StatusMethodNotAllowed, errorResponse{"only GET is allowed"})
+			return
+		}
+		if !isLoopbackHost(r.Host) {
+			writeJSON(w, http.StatusForbidden, errorResponse{"only localhost is allowed"})
+			return
+		}
+		if !isAllowedSite(r, origins) {
+			writeJSON(w, http.StatusForbidden, errorResponse{"cross-site requests are not allowed, try --allow-origin"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isLoopbackHost reports whether the host (with or without the port) is localhost or a loopback address
+func isLoopbackHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	return ip != nil && ip.IsLoopback()
+}
+
+// isLoopbackAddr reports whether the address to listen on is a loopback one,
+// the empty host (eg: :7777) listens on all the interfaces
+func isLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	return err == nil && host != "" && isLoopbackHost(host)
+}
+
+// isAllowedSite reports whether the request is made by the user (eg: curl, the address bar or an editor),
+// or from the allowed origins. The browsers send Origin by fetch and Sec-Fetch-Site by the navigations.
+func isAllowedSite(r *http.Request, origins []string) bool {
+	if origin := r.Header.Get("Origin"); origin != "" {
+		return slices.Contains(origins, origin)
+	}
+	switch r.Header.Get("Sec-Fetch-Site") {
+	case "", "none", "same-origin":
+		return true
+	}
+	return false
+}
+
+// handleResolve resolves the path by the same parameters as the command line options:
+// path, line, branch, ref, permalink, default_branch, current_branch, tag, match and symbol
+func handleResolve(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	path := query.Get("path")
+	if path == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{"path is required"})
+		return
+	}
+	lines, err := ghopen.ParseLines(query.Get("line"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var response resolveResponse
+	resolver := ghopen.Resolver{
+		Warn:    func(msg string) { response.Warnings = append(response.Warnings, msg) },
+		Timeout: opts.Timeout,
+		Backend: opts.Backend,
+	}
+	response.Location, err = resolver.Resolve(r.Context(), path, ghopen.Options{
+		Branch:        query.Get("branch"),
+		Ref:           query.Get("ref"),
+		Permalink:     queryBool(query, "permalink"),
+		DefaultBranch: queryBool(query, "default_branch"),
+		CurrentBranch: queryBool(query, "current_branch"),
+		Tag:           query.Get("tag"),
+		Lines:         lines,
+		Match:         query.Get("match"),
+		Symbol:        query.Get("symbol"),
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// handleOpen redirects to the local file of the url in the editor
+func handleOpen(w http.ResponseWriter, r *http.Request, editorURL string) {
+	rawURL := r.URL.Query().Get("url")
+	if rawURL == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{"url is required"})
+		return
+	}
+	resolver := ghopen.Resolver{Timeout: opts.Timeout, Backend: opts.Backend}
+	file, err := resolver.FindLocal(r.Context(), rawURL, ghopen.SearchRoots(r.Context()))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	line := 1
+	if len(file.Lines) > 0 {
+		line = file.Lines[0].Start
+	}
+	http.Redirect(w, r, editorLink(editorURL, file.Path, line), http.StatusFound)
+}
+
+// editorLink returns the url of the editor opening the file at the line
+//   vscode://file{path}:{line} => vscode://file/src/gh-open/main.go:10
+func editorLink(editorURL, path string, line int) string {
+	escaped := (&url.URL{Path: path}).EscapedPath()
+	return strings.NewReplacer("{path}", escaped, "{line}", strconv.Itoa(line)).Replace(editorURL)
+}
+
+// queryBool reports whether the query parameter is set to true (eg: ?permalink or ?permalink=1)
+func queryBool(query url.Values, name string) bool {
+	value := query.Get(name)
+	if value == "" {
+		return query.Has(name)
+	}
+	b, err := strconv.ParseBool(value)
+	return err == nil && b
+}
+
+// writeError writes the error with the HTTP status of its kind
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ghopen.ErrNotExist), errors.Is(err, ghopen.ErrUnknownRevision), errors.Is(err, ghopen.ErrNotRepository):
+		status = http.StatusNotFound
+	case errors.Is(err, ghopen.ErrInvalidLine), errors.Is(err, ghopen.ErrNoMatch), errors.Is(err, ghopen.ErrInvalidOption):
+		status = http.StatusBadRequest
+	case errors.Is(err, ghopen.ErrNoRemote), errors.Is(err, ghopen.ErrUnsupportedHost), errors.Is(err, ghopen.ErrNotPushed):
+		status = http.StatusUnprocessableEntity
+	}
+	writeJSON(w, status, errorResponse{err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
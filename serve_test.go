@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// mkServeRepo creates a repository with one commit containing README.md
+func mkServeRepo(t *testing.T, dir string) {
+	t.Helper()
+	os.MkdirAll(dir, 0777)
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("line1\nline2\nline3\n"), 0666)
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "master"},
+		{"add", "README.md"},
+		{"-c", "user.name=gh-open", "-c", "user.email=gh-open@example.com", "commit", "-q", "-m", "initial"},
+		{"remote", "add", "origin", "git@github.com:inouet/gh-open.git"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+}
+
+func TestServe(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(home, ".gitconfig"))
+	t.Setenv("GHQ_ROOT", "")
+	repoDir := filepath.Join(home, "src", "gh-open")
+	mkServeRepo(t, repoDir)
+	readme := filepath.Join(repoDir, "README.md")
+
+	server := httptest.NewServer(newServeHandler("vscode://file{path}:{line}", []string{"chrome-extension://gh-open"}))
+	defer server.Close()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+
+	cases := map[string]struct {
+		method       string
+		path         string
+		host         string
+		header       map[string]string
+		wantStatus   int
+		wantURL      string
+		wantLocation string
+	}{
+		"resolve": {
+			path:       "/resolve?path=" + url.QueryEscape(readme) + "&line=2-3&branch=master",
+			wantStatus: http.StatusOK,
+			wantURL:    "https://github.com/inouet/gh-open/tree/master/README.md#L2-L3",
+		},
+		"resolve-without-path": {
+			path:       "/resolve",
+			wantStatus: http.StatusBadRequest,
+		},
+		"resolve-invalid-line": {
+			path:       "/resolve?path=" + url.QueryEscape(readme) + "&line=x",
+			wantStatus: http.StatusBadRequest,
+		},
+		"resolve-not-exist": {
+			path:       "/resolve?path=" + url.QueryEscape(filepath.Join(repoDir, "nope.go")),
+			wantStatus: http.StatusNotFound,
+		},
+		"open": {
+			path:         "/open?url=" + url.QueryEscape("https://github.com/inouet/gh-open/blob/master/README.md#L2"),
+			wantStatus:   http.StatusFound,
+			wantLocation: "vscode://file" + readme + ":2",
+		},
+		"open-no-clone": {
+			path:       "/open?url=" + url.QueryEscape("https://github.com/inouet/other/blob/master/README.md"),
+			wantStatus: http.StatusNotFound,
+		},
+		"other-host": {
+			path:       "/resolve?path=" + url.QueryEscape(readme),
+			host:       "attacker.example.com",
+			wantStatus: http.StatusForbidden,
+		},
+		"cross-site-fetch": {
+			path:       "/resolve?path=" + url.QueryEscape(readme),
+			header:     map[string]string{"Origin": "https://attacker.example.com", "Sec-Fetch-Site": "cross-site"},
+			wantStatus: http.StatusForbidden,
+		},
+		"cross-site-navigation": {
+			path:       "/open?url=" + url.QueryEscape("https://github.com/inouet/gh-open/blob/master/README.md#L2"),
+			header:     map[string]string{"Sec-Fetch-Site": "cross-site"},
+			wantStatus: http.StatusForbidden,
+		},
+		"allowed-origin": {
+			path:       "/resolve?path=" + url.QueryEscape(readme) + "&branch=master",
+			header:     map[string]string{"Origin": "chrome-extension://gh-open", "Sec-Fetch-Site": "none"},
+			wantStatus: http.StatusOK,
+			wantURL:    "https://github.com/inouet/gh-open/tree/master/README.md",
+		},
+		"address-bar": {
+			path:       "/resolve?path=" + url.QueryEscape(readme) + "&branch=master",
+			header:     map[string]string{"Sec-Fetch-Site": "none"},
+			wantStatus: http.StatusOK,
+			wantURL:    "https://github.com/inouet/gh-open/tree/master/README.md",
+		},
+		"post": {
+			method:     http.MethodPost,
+			path:       "/resolve?path=" + url.QueryEscape(readme),
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			method := c.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req, _ := http.NewRequest(method, server.URL+c.path, nil)
+			if c.host != "" {
+				req.Host = c.host
+			}
+			for k, v := range c.header {
+				req.Header.Set(k, v)
+			}
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if res.StatusCode != c.wantStatus {
+				t.Errorf("want %d, got %d\n", c.wantStatus, res.StatusCode)
+			}
+			if c.wantURL != "" {
+				var got resolveResponse
+				json.NewDecoder(res.Body).Decode(&got)
+				if got.URL != c.wantURL {
+					t.Errorf("want '%s', got '%s'\n", c.wantURL, got.URL)
+				}
+			}
+			if c.wantLocation != "" && res.Header.Get("Location") != c.wantLocation {
+				t.Errorf("want '%s', got '%s'\n", c.wantLocation, res.Header.Get("Location"))
+			}
+		})
+	}
+}
+
+func TestEditorLink(t *testing.T) {
+	cases := []struct {
+		editorURL string
+		path      string
+		want      string
+	}{
+		{editorURL: "vscode://file{path}:{line}", path: "/src/gh-open/main.go", want: "vscode://file/src/gh-open/main.go:10"},
+		{editorURL: "idea://open?file={path}&line={line}", path: "/src/my app/main.go", want: "idea://open?file=/src/my%20app/main.go&line=10"},
+	}
+	for _, c := range cases {
+		got := editorLink(c.editorURL, c.path, 10)
+		if got != c.want {
+			t.Errorf("want '%s', got '%s'\n", c.want, got)
+		}
+	}
+}
+
+func TestIsLoopbackAddr(t *testing.T) {
+	cases := map[string]bool{
+		"127.0.0.1:7777": true,
+		"localhost:7777": true,
+		"[::1]:7777":     true,
+		":7777":          false,
+		"0.0.0.0:7777":   false,
+		"[::]:7777":      false,
+		"10.0.0.1:7777":  false,
+		"127.0.0.1":      false,
+	}
+	for addr, want := range cases {
+		if got := isLoopbackAddr(addr); got != want {
+			t.Errorf("'%s' want %v, got %v\n", addr, want, got)
+		}
+	}
+}
+
+func TestIsLoopbackHost(t *testing.T) {
+	cases := map[string]bool{
+		"localhost:7777":        true,
+		"127.0.0.1:7777":        true,
+		"[::1]:7777":            true,
+		"127.0.0.1":             true,
+		"example.com:7777":      false,
+		"192.168.1.10:7777":     false,
+		"localhost.example.com": false,
+	}
+	for host, want := range cases {
+		if got := isLoopbackHost(host); got != want {
+			t.Errorf("'%s' want %v, got %v\n", host, want, got)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// osc8Link returns the text as a clickable hyperlink to the url for terminals supporting OSC 8
+func osc8Link(url, text string) string {
+	return "\x1b]8;;" + url + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
+
+// qrText renders the url as a QR code of Unicode half blocks, two modules per character
+func qrText(url string) (string, error) {
+	qr, err := qrcode.New(url, qrcode.Low)
+	if err != nil {
+		return "", err
+	}
+	return qr.ToSmallString(false), nil
+}
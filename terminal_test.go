@@ -0,0 +1,25 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOSC8Link(t *testing.T) {
+	got := osc8Link("https://github.com/inouet/gh-open", "inouet/gh-open")
+	want := "\x1b]8;;https://github.com/inouet/gh-open\x1b\\inouet/gh-open\x1b]8;;\x1b\\"
+	if got != want {
+		t.Errorf("want %q, got %q\n", want, got)
+	}
+}
+
+func TestQRText(t *testing.T) {
+	got, err := qrText("https://github.com/inouet/gh-open")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimRight(got, "\n"), "\n")
+	if len(lines) < 10 || !strings.ContainsAny(got, "█▀▄") {
+		t.Errorf("unexpected QR code:\n%s", got)
+	}
+}